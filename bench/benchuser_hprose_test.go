@@ -0,0 +1,64 @@
+// Code generated by hprose-gen. DO NOT EDIT.
+
+package hprosebench
+
+import (
+	"strings"
+
+	hprose "github.com/hprose/hprose-go"
+)
+
+// WriteTo writes the fields of BenchUser to writer
+func (v *BenchUser) WriteTo(writer *hprose.Writer) (err error) {
+	if err = writer.WriteInt64(int64(v.ID)); err != nil {
+		return
+	}
+	if err = writer.WriteStringWithRef(v.Name); err != nil {
+		return
+	}
+	if err = writer.WriteStringWithRef(v.Email); err != nil {
+		return
+	}
+	if err = writer.WriteFloat64(v.Score); err != nil {
+		return
+	}
+	if err = writer.WriteBool(v.Active); err != nil {
+		return
+	}
+	if err = writer.Serialize(v.Tags); err != nil {
+		return
+	}
+	if err = writer.Serialize(v.Birthday); err != nil {
+		return
+	}
+	return nil
+}
+
+// ReadFrom reads the fields of BenchUser from reader
+func (v *BenchUser) ReadFrom(reader *hprose.Reader) (err error) {
+	for _, name := range reader.Fields() {
+		switch strings.ToLower(name) {
+		case "id":
+			err = reader.Unserialize(&v.ID)
+		case "name":
+			err = reader.Unserialize(&v.Name)
+		case "email":
+			err = reader.Unserialize(&v.Email)
+		case "score":
+			err = reader.Unserialize(&v.Score)
+		case "active":
+			err = reader.Unserialize(&v.Active)
+		case "tags":
+			err = reader.Unserialize(&v.Tags)
+		case "birthday":
+			err = reader.Unserialize(&v.Birthday)
+		default:
+			var value interface{}
+			err = reader.Unserialize(&value)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
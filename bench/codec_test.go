@@ -0,0 +1,99 @@
+package hprosebench
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hprose/hprose-go"
+)
+
+//go:generate go run ../cmd/hprose-gen/main.go ../cmd/hprose-gen/generator.go ../cmd/hprose-gen/codec.go -codec -type=BenchUser -output=benchuser_hprose_test.go
+
+// BenchUser has the generated WriteTo and ReadFrom
+type BenchUser struct {
+	ID       int
+	Name     string
+	Email    string
+	Score    float64
+	Active   bool
+	Tags     []string
+	Birthday time.Time
+}
+
+// benchUserPlain is encoded by reflection
+type benchUserPlain struct {
+	ID       int
+	Name     string
+	Email    string
+	Score    float64
+	Active   bool
+	Tags     []string
+	Birthday time.Time
+}
+
+func init() {
+	hprose.ClassManager.Register(reflect.TypeOf(BenchUser{}), "BenchUser")
+	hprose.ClassManager.Register(reflect.TypeOf(benchUserPlain{}), "BenchUserPlain")
+}
+
+func benchUsers() ([]BenchUser, []benchUserPlain) {
+	users := make([]BenchUser, 100)
+	plains := make([]benchUserPlain, 100)
+	birthday := time.Date(1980, 12, 1, 0, 0, 0, 0, time.UTC)
+	for i := range users {
+		users[i] = BenchUser{i, "user", "user@hprose.com", 98.5, true, []string{"a", "b"}, birthday}
+		plains[i] = benchUserPlain(users[i])
+	}
+	return users, plains
+}
+
+func benchmarkSerialize(b *testing.B, v interface{}) {
+	buf := new(bytes.Buffer)
+	writer := hprose.NewWriter(buf, false)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		writer.Reset()
+		writer.Serialize(v)
+	}
+}
+
+func benchmarkUnserialize(b *testing.B, v interface{}, p interface{}) {
+	buf := new(bytes.Buffer)
+	hprose.NewWriter(buf, false).Serialize(v)
+	data := buf.Bytes()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hprose.NewReader(hprose.NewBytesReader(data), false).Unserialize(p)
+	}
+}
+
+// BenchmarkSerializeReflect is ...
+func BenchmarkSerializeReflect(b *testing.B) {
+	_, plains := benchUsers()
+	benchmarkSerialize(b, plains)
+}
+
+// BenchmarkSerializeGenerated is ...
+func BenchmarkSerializeGenerated(b *testing.B) {
+	users, _ := benchUsers()
+	benchmarkSerialize(b, users)
+}
+
+// BenchmarkUnserializeReflect is ...
+func BenchmarkUnserializeReflect(b *testing.B) {
+	_, plains := benchUsers()
+	var result []benchUserPlain
+	benchmarkUnserialize(b, plains, &result)
+}
+
+// BenchmarkUnserializeGenerated is ...
+func BenchmarkUnserializeGenerated(b *testing.B) {
+	users, _ := benchUsers()
+	var result []BenchUser
+	benchmarkUnserialize(b, users, &result)
+}
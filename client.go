@@ -12,7 +12,7 @@
  *                                                        *
  * hprose client for Go.                                  *
  *                                                        *
- * LastModified: Jul 3, 2015                              *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -105,6 +105,7 @@ package hprose
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -112,6 +113,7 @@ import (
 	"reflect"
 	"runtime/debug"
 	"strings"
+	"time"
 )
 
 // InvokeOptions is the invoke options of hprose client
@@ -119,18 +121,40 @@ type InvokeOptions struct {
 	ByRef      interface{} // true, false, nil
 	SimpleMode interface{} // true, false, nil
 	ResultMode ResultMode
+	// Oneway marks the invoking as a notification which expects no
+	// response, the filters like JSONRPCClientFilter use it.
+	Oneway bool
+	// Idempotent marks the invoking as safe to repeat, only the idempotent
+	// invokings are retried by the RetryPolicy of the client.
+	Idempotent bool
 }
 
+// onewayContextKey is the key of the oneway flags of the invokings in the
+// client context, which is used by the client filters
+const onewayContextKey = "hprose.oneway"
+
+// methodContextKey is the key of the method name of the invoking in the
+// client context, the batches have no method name
+const methodContextKey = "hprose.method"
+
 // Client is hprose client
 type Client interface {
 	UseService(...interface{})
 	Invoke(string, []interface{}, *InvokeOptions, interface{}) <-chan error
+	InvokeContext(context.Context, string, []interface{}, *InvokeOptions, interface{}) <-chan error
+	InvokeWith(context.Context, string, *InvokeOptions, func(*Writer) error, func(*Reader) error) error
+	Batch() *Batch
 	Uri() string
 	SetUri(string)
 	GetFilter() Filter
 	SetFilter(filter Filter)
 	AddFilter(filter Filter)
 	RemoveFilter(filter Filter)
+	Codec() Codec
+	SetCodec(codec Codec)
+	Use(handler ...InvokeHandler)
+	AddBeforeFilterHandler(handler ...FilterHandler)
+	AddAfterFilterHandler(handler ...FilterHandler)
 	TLSClientConfig() *tls.Config
 	SetTLSClientConfig(config *tls.Config)
 	SetKeepAlive(enable bool)
@@ -141,6 +165,7 @@ type Client interface {
 type ClientContext struct {
 	*BaseContext
 	Client
+	Attempt int // the attempt number of the invoking, starts from 1
 }
 
 // Transporter is the hprose client transporter
@@ -148,15 +173,28 @@ type Transporter interface {
 	SendAndReceive(uri string, data []byte) ([]byte, error)
 }
 
+// ContextTransporter is the hprose client transporter which can abort
+// the sending and receiving when the ctx is done
+type ContextTransporter interface {
+	SendAndReceiveContext(ctx context.Context, uri string, data []byte) ([]byte, error)
+}
+
 // BaseClient is the hprose base client
 type BaseClient struct {
 	Transporter
 	Client
-	ByRef        bool
-	SimpleMode   bool
-	DebugEnabled bool
-	uri          *url.URL
-	filters      []Filter
+	ByRef                bool
+	SimpleMode           bool
+	DebugEnabled         bool
+	DeadlineEnabled      bool            // send the remaining time before the ctx deadline to the service
+	RetryPolicy          *RetryPolicy    // retries the failed idempotent invokings, nil is no retry
+	CircuitBreaker       *CircuitBreaker // fails fast when the service is down, nil is disabled
+	uri                  *url.URL
+	filters              []Filter
+	codec                Codec
+	invokeHandlers       invokeHandlers
+	beforeFilterHandlers filterHandlers
+	afterFilterHandlers  filterHandlers
 }
 
 var clientFactories = make(map[string]func(string) Client)
@@ -214,6 +252,29 @@ func (client *BaseClient) SetFilter(filter Filter) {
 	}
 }
 
+// Use adds the invoke handlers, the first added handler is called first.
+// The handlers get the arguments and return the results of Invoke. For
+// InvokeWith, they get the arguments decoded from the encoded arguments and
+// the result decoded from the encoded result, the changed arguments and the
+// results returned by the handlers are encoded again. The batches don't call
+// them.
+func (client *BaseClient) Use(handler ...InvokeHandler) {
+	client.invokeHandlers = append(client.invokeHandlers, handler...)
+}
+
+// AddBeforeFilterHandler adds the handlers which get the request before the
+// output filters and the response after the input filters
+func (client *BaseClient) AddBeforeFilterHandler(handler ...FilterHandler) {
+	client.beforeFilterHandlers = append(client.beforeFilterHandlers, handler...)
+}
+
+// AddAfterFilterHandler adds the handlers which get the request after the
+// output filters and the response before the input filters, they wrap the
+// transport
+func (client *BaseClient) AddAfterFilterHandler(handler ...FilterHandler) {
+	client.afterFilterHandlers = append(client.afterFilterHandlers, handler...)
+}
+
 // AddFilter add a filter
 func (client *BaseClient) AddFilter(filter Filter) {
 	client.filters = append(client.filters, filter)
@@ -239,7 +300,17 @@ func (client *BaseClient) RemoveFilter(filter Filter) {
 // UseService (uri string, remoteObject interface{})
 // UseService (remoteObject interface{}, namespace string)
 // UseService (uri string, remoteObject interface{}, namespace string)
+//
+// The remoteObject is a pointer to struct stub or a pointer to interface
+// variable, an InterfaceOptions can be the last argument for the interface.
 func (client *BaseClient) UseService(args ...interface{}) {
+	var options InterfaceOptions
+	if n := len(args); n > 1 {
+		if o, ok := args[n-1].(InterfaceOptions); ok {
+			options = o
+			args = args[:n-1]
+		}
+	}
 	switch len(args) {
 	case 1:
 		switch arg0 := args[0].(type) {
@@ -252,8 +323,8 @@ func (client *BaseClient) UseService(args ...interface{}) {
 			client.SetUri(*arg0)
 			return
 		default:
-			if isStructPointer(arg0) {
-				client.createStub(arg0, "")
+			if isStubPointer(arg0) {
+				client.useStub(arg0, "", options)
 				return
 			}
 		}
@@ -268,18 +339,18 @@ func (client *BaseClient) UseService(args ...interface{}) {
 		default:
 			switch arg1 := args[1].(type) {
 			case nil:
-				if isStructPointer(arg0) {
-					client.createStub(arg0, "")
+				if isStubPointer(arg0) {
+					client.useStub(arg0, "", options)
 					return
 				}
 			case string:
-				if isStructPointer(arg0) {
-					client.createStub(arg0, arg1)
+				if isStubPointer(arg0) {
+					client.useStub(arg0, arg1, options)
 					return
 				}
 			case *string:
-				if isStructPointer(arg0) {
-					client.createStub(arg0, *arg1)
+				if isStubPointer(arg0) {
+					client.useStub(arg0, *arg1, options)
 					return
 				}
 			}
@@ -288,8 +359,8 @@ func (client *BaseClient) UseService(args ...interface{}) {
 		if args[1] == nil {
 			panic("The arguments can't be nil.")
 		}
-		if isStructPointer(args[1]) {
-			client.createStub(args[1], "")
+		if isStubPointer(args[1]) {
+			client.useStub(args[1], "", options)
 			return
 		}
 	case 3:
@@ -306,16 +377,16 @@ func (client *BaseClient) UseService(args ...interface{}) {
 		if args[1] == nil {
 			panic("The arguments can't be nil.")
 		}
-		if isStructPointer(args[1]) {
+		if isStubPointer(args[1]) {
 			switch arg2 := args[2].(type) {
 			case nil:
-				client.createStub(args[1], "")
+				client.useStub(args[1], "", options)
 				return
 			case string:
-				client.createStub(args[1], arg2)
+				client.useStub(args[1], arg2, options)
 				return
 			case *string:
-				client.createStub(args[1], *arg2)
+				client.useStub(args[1], *arg2, options)
 				return
 			}
 		}
@@ -325,6 +396,15 @@ func (client *BaseClient) UseService(args ...interface{}) {
 
 // Invoke the remote method
 func (client *BaseClient) Invoke(name string, args []interface{}, options *InvokeOptions, result interface{}) <-chan error {
+	return client.InvokeContext(context.Background(), name, args, options, result)
+}
+
+// InvokeContext invoke the remote method with the ctx,
+// the invocation is aborted and ctx.Err() is returned when the ctx is done
+func (client *BaseClient) InvokeContext(ctx context.Context, name string, args []interface{}, options *InvokeOptions, result interface{}) <-chan error {
+	if ctx == nil {
+		panic("The argument ctx can't be nil")
+	}
 	if result == nil {
 		panic("The argument result can't be nil")
 	}
@@ -340,12 +420,212 @@ func (client *BaseClient) Invoke(name string, args []interface{}, options *Invok
 	for i := 0; i < count; i++ {
 		a[i] = v.Index(i).Elem()
 	}
-	return client.invoke(name, a, options, r)
+	return client.invoke(ctx, name, a, options, r)
+}
+
+// InvokeWith invoke the remote method synchronously without reflection,
+// encode writes the arguments list, and decode reads the result.
+// encode can be nil when there is no argument, and decode can be nil
+// when the result is ignored.
+// It's used by the code generated by hprose-gen.
+func (client *BaseClient) InvokeWith(ctx context.Context, name string, options *InvokeOptions, encode func(writer *Writer) error, decode func(reader *Reader) error) (err error) {
+	if options == nil {
+		options = new(InvokeOptions)
+	}
+	context := new(ClientContext)
+	context.BaseContext = NewBaseContext()
+	context.Client = client.Client
+	context.SetContext(ctx)
+	defer func() {
+		if e := recover(); e != nil && err == nil {
+			err = client.recoverError(e)
+		}
+	}()
+	if len(client.invokeHandlers) == 0 {
+		return client.retry(options, context, func() error {
+			return client.invokeWith(name, options, encode, decode, context)
+		})
+	}
+	return client.invokeWithHandlers(name, options, encode, decode, context)
+}
+
+// invokeWithHandlers calls InvokeWith by the invoke handlers. The handlers
+// get the decoded arguments and the decoded result, the encoded arguments and
+// result are used as they are unless the handlers change them, then the
+// changed values are encoded again.
+func (client *BaseClient) invokeWithHandlers(name string, options *InvokeOptions, encode func(writer *Writer) error, decode func(reader *Reader) error, context *ClientContext) (err error) {
+	var raw []byte
+	var args []reflect.Value
+	if encode != nil {
+		if raw, args, err = client.encodeArgs(options, encode); err != nil {
+			return err
+		}
+	}
+	sent := interfaces(args)
+	var result []byte
+	var value interface{}
+	handler := client.invokeHandlers.handler(func(name string, args []reflect.Value, context Context) ([]reflect.Value, error) {
+		request := raw
+		if !reflect.DeepEqual(interfaces(args), sent) {
+			var err error
+			if request, err = client.serialize(options, interfaces(args)); err != nil {
+				return nil, err
+			}
+		}
+		var encode func(writer *Writer) error
+		if request != nil {
+			encode = func(writer *Writer) error {
+				_, err := writer.Stream.Write(request)
+				return err
+			}
+		}
+		clientContext := context.(*ClientContext)
+		err := client.retry(options, clientContext, func() error {
+			return client.invokeWith(name, options, encode, func(reader *Reader) (err error) {
+				if result, err = reader.ReadRaw(); err != nil {
+					return err
+				}
+				return NewReader(NewBytesReader(result), false).Unserialize(&value)
+			}, clientContext)
+		})
+		if err != nil {
+			return nil, err
+		}
+		v := value
+		return []reflect.Value{reflect.ValueOf(&v).Elem()}, nil
+	})
+	results, err := handler(name, args, context)
+	if err != nil || decode == nil {
+		return err
+	}
+	if result == nil || !reflect.DeepEqual(interfaces(results), []interface{}{value}) {
+		switch len(results) {
+		case 0:
+			result, err = client.serialize(options, nil)
+		case 1:
+			result, err = client.serialize(options, results[0].Interface())
+		default:
+			result, err = client.serialize(options, interfaces(results))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return decode(NewReader(NewBytesReader(result), false))
+}
+
+// serialize encodes the value in the simple mode of the options
+func (client *BaseClient) serialize(options *InvokeOptions, v interface{}) ([]byte, error) {
+	simple := client.SimpleMode
+	if s, ok := options.SimpleMode.(bool); ok {
+		simple = s
+	}
+	return Serialize(v, simple)
+}
+
+func interfaces(values []reflect.Value) []interface{} {
+	result := make([]interface{}, len(values))
+	for i := range values {
+		result[i] = values[i].Interface()
+	}
+	return result
+}
+
+// encodeArgs encodes the arguments of InvokeWith once, and decodes them for
+// the invoke handlers, the encoded arguments are sent as they are.
+func (client *BaseClient) encodeArgs(options *InvokeOptions, encode func(writer *Writer) error) (raw []byte, args []reflect.Value, err error) {
+	simple := client.SimpleMode
+	if s, ok := options.SimpleMode.(bool); ok {
+		simple = s
+	}
+	buf := new(bytes.Buffer)
+	if err = encode(NewWriter(buf, simple)); err != nil {
+		return nil, nil, err
+	}
+	var values []interface{}
+	if err = NewReader(NewBytesReader(buf.Bytes()), simple).Unserialize(&values); err != nil {
+		return nil, nil, err
+	}
+	args = make([]reflect.Value, len(values))
+	for i := range values {
+		args[i] = reflect.ValueOf(&values[i]).Elem()
+	}
+	return buf.Bytes(), args, nil
+}
+
+func (client *BaseClient) invokeWith(name string, options *InvokeOptions, encode func(writer *Writer) error, decode func(reader *Reader) error, context *ClientContext) (err error) {
+	defer func() {
+		if e := recover(); e != nil && err == nil {
+			err = client.recoverError(e)
+		}
+	}()
+	ctx := context.Context()
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	context.SetString(methodContextKey, name)
+	if client.codec != nil {
+		return errors.New("InvokeWith doesn't support the codec")
+	}
+	buf := new(bytes.Buffer)
+	if err = client.writeHeaders(buf, context); err != nil {
+		return err
+	}
+	simple := client.SimpleMode
+	if s, ok := options.SimpleMode.(bool); ok {
+		simple = s
+	}
+	writer := NewWriter(buf, simple)
+	buf.WriteByte(TagCall)
+	if err = writer.WriteString(name); err != nil {
+		return err
+	}
+	if encode != nil {
+		writer.Reset()
+		if err = encode(writer); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(TagEnd)
+	context.SetInterface(onewayContextKey, []bool{options.Oneway})
+	data, err := client.exchange(buf.Bytes(), context)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 || data[len(data)-1] != TagEnd {
+		return errors.New("Wrong Response: \r\n" + string(data))
+	}
+	istream := NewBytesReader(data)
+	reader := NewReader(istream, false)
+	var tag byte
+	for tag, err = istream.ReadByte(); err == nil && tag != TagEnd; tag, err = istream.ReadByte() {
+		switch tag {
+		case TagResult:
+			reader.Reset()
+			if decode == nil {
+				_, err = reader.ReadRaw()
+			} else {
+				err = decode(reader)
+			}
+			if err != nil {
+				return err
+			}
+		case TagError:
+			var e *RemoteError
+			if e, err = readError(reader, istream); err != nil {
+				return err
+			}
+			return e
+		default:
+			return errors.New("Wrong Response: \r\n" + string(data))
+		}
+	}
+	return err
 }
 
 // private methods
 
-func (client *BaseClient) invoke(name string, args []reflect.Value, options *InvokeOptions, result []reflect.Value) <-chan error {
+func (client *BaseClient) invoke(ctx context.Context, name string, args []reflect.Value, options *InvokeOptions, result []reflect.Value) <-chan error {
 	if options == nil {
 		options = new(InvokeOptions)
 	}
@@ -368,6 +648,7 @@ func (client *BaseClient) invoke(name string, args []reflect.Value, options *Inv
 	context := new(ClientContext)
 	context.BaseContext = NewBaseContext()
 	context.Client = client.Client
+	context.SetContext(ctx)
 	if async {
 		return client.asyncInvoke(name, args, options, result, context)
 	}
@@ -379,23 +660,132 @@ func (client *BaseClient) invoke(name string, args []reflect.Value, options *Inv
 func (client *BaseClient) syncInvoke(name string, args []reflect.Value, options *InvokeOptions, result []reflect.Value, context *ClientContext) (err error) {
 	defer func() {
 		if e := recover(); e != nil && err == nil {
-			if client.DebugEnabled {
-				err = fmt.Errorf("%v\r\n%s", e, debug.Stack())
-			} else {
-				err = fmt.Errorf("%v", e)
+			err = client.recoverError(e)
+		}
+	}()
+	handler := client.invokeHandlers.handler(func(name string, args []reflect.Value, context Context) ([]reflect.Value, error) {
+		var out []reflect.Value
+		err := client.retry(options, context.(*ClientContext), func() error {
+			out = make([]reflect.Value, len(result))
+			for i := range result {
+				out[i] = reflect.New(result[i].Type()).Elem()
 			}
+			return client.remoteInvoke(name, args, options, out, context.(*ClientContext))
+		})
+		return out, err
+	})
+	results, err := handler(name, args, context)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(result) && i < len(results); i++ {
+		result[i].Set(results[i])
+	}
+	return nil
+}
+
+func (client *BaseClient) remoteInvoke(name string, args []reflect.Value, options *InvokeOptions, result []reflect.Value, context *ClientContext) (err error) {
+	defer func() {
+		if e := recover(); e != nil && err == nil {
+			err = client.recoverError(e)
 		}
 	}()
-	if odata, e := client.doOutput(name, args, options, context); e != nil {
+	ctx := context.Context()
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	context.SetString(methodContextKey, name)
+	if odata, e := client.doOutput(name, args, options, len(result), context); e != nil {
 		err = e
-	} else if idata, e := client.SendAndReceive(client.Uri(), odata); e != nil {
+	} else if idata, e := client.exchange(odata, context); e != nil {
 		err = e
+	} else if client.codec != nil {
+		err = client.doCodecInput(idata, args, result, context)
 	} else if e := client.doIntput(idata, args, options, result, context); e != nil {
 		err = e
 	}
 	return err
 }
 
+// recoverError returns the recovered error as is (or wrapped with the stack
+// when DebugEnabled), so the callers can match it by errors.Is
+func (client *BaseClient) recoverError(e interface{}) error {
+	err, ok := e.(error)
+	if !ok {
+		err = fmt.Errorf("%v", e)
+	}
+	if client.DebugEnabled {
+		return fmt.Errorf("%w\r\n%s", err, debug.Stack())
+	}
+	return err
+}
+
+// exchange sends the request and receives the response by the filter
+// handlers and the filters
+func (client *BaseClient) exchange(request []byte, context *ClientContext) ([]byte, error) {
+	return client.beforeFilterHandlers.handler(client.filter)(request, context)
+}
+
+func (client *BaseClient) filter(request []byte, context Context) (response []byte, err error) {
+	defer func() {
+		if e := recover(); e != nil && err == nil {
+			response, err = nil, client.recoverError(e)
+		}
+	}()
+	clientContext := context.(*ClientContext)
+	request = client.outputFilter(request, clientContext)
+	if response, err = client.transfer(request, clientContext); err != nil {
+		return nil, err
+	}
+	return client.inputFilter(response, clientContext), nil
+}
+
+// transfer sends the filtered request and receives the response by the
+// after filter handlers
+func (client *BaseClient) transfer(request []byte, context *ClientContext) ([]byte, error) {
+	return client.afterFilterHandlers.handler(client.transport)(request, context)
+}
+
+func (client *BaseClient) transport(request []byte, context Context) ([]byte, error) {
+	clientContext := context.(*ClientContext)
+	if trans, ok := client.Transporter.(dispatcher); ok {
+		return trans.dispatch(request, clientContext)
+	}
+	return client.CircuitBreaker.call(client.Uri(), clientContext, func() ([]byte, error) {
+		return client.sendAndReceive(clientContext.Context(), request)
+	})
+}
+
+// dispatcher is the transporter which sends the requests to the endpoints
+// by itself, such as the transporter of ClusterClient
+type dispatcher interface {
+	dispatch(request []byte, context *ClientContext) ([]byte, error)
+}
+
+func (client *BaseClient) sendAndReceive(ctx context.Context, data []byte) ([]byte, error) {
+	if trans, ok := client.Transporter.(ContextTransporter); ok {
+		return trans.SendAndReceiveContext(ctx, client.Uri(), data)
+	}
+	if ctx.Done() == nil {
+		return client.SendAndReceive(client.Uri(), data)
+	}
+	type response struct {
+		data []byte
+		err  error
+	}
+	recv := make(chan response, 1)
+	go func() {
+		data, err := client.SendAndReceive(client.Uri(), data)
+		recv <- response{data, err}
+	}()
+	select {
+	case r := <-recv:
+		return r.data, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 func (client *BaseClient) asyncInvoke(name string, args []reflect.Value, options *InvokeOptions, result []reflect.Value, context *ClientContext) <-chan error {
 	length := len(result)
 	sender := make([]reflect.Value, length)
@@ -418,8 +808,57 @@ func (client *BaseClient) asyncInvoke(name string, args []reflect.Value, options
 	return errChan
 }
 
-func (client *BaseClient) doOutput(name string, args []reflect.Value, options *InvokeOptions, context *ClientContext) (data []byte, err error) {
+func (client *BaseClient) doOutput(name string, args []reflect.Value, options *InvokeOptions, results int, context *ClientContext) (data []byte, err error) {
+	if client.codec != nil {
+		var call *CodecCall
+		if call, err = client.newCodecCall(name, args, options, results); err != nil {
+			return nil, err
+		}
+		return client.encodeCodecRequest([]*CodecCall{call}, context)
+	}
 	buf := new(bytes.Buffer)
+	if err = client.writeHeaders(buf, context); err != nil {
+		return nil, err
+	}
+	if err = client.writeCall(buf, name, args, options); err != nil {
+		return nil, err
+	}
+	if err = buf.WriteByte(TagEnd); err != nil {
+		return nil, err
+	}
+	context.SetInterface(onewayContextKey, []bool{options.Oneway})
+	return buf.Bytes(), nil
+}
+
+func (client *BaseClient) outputFilter(data []byte, context *ClientContext) []byte {
+	n := len(client.filters)
+	for i := 0; i < n; i++ {
+		data = client.filters[i].OutputFilter(data, context)
+	}
+	return data
+}
+
+func (client *BaseClient) inputFilter(data []byte, context *ClientContext) []byte {
+	for i := len(client.filters) - 1; i >= 0; i-- {
+		data = client.filters[i].InputFilter(data, context)
+	}
+	return data
+}
+
+func (client *BaseClient) writeHeaders(buf *bytes.Buffer, context *ClientContext) (err error) {
+	if client.DeadlineEnabled {
+		if deadline, ok := context.Context().Deadline(); ok {
+			timeout := int(time.Until(deadline) / time.Millisecond)
+			if timeout < 1 {
+				timeout = 1
+			}
+			return writeHeaders(buf, map[string]interface{}{timeoutHeader: timeout})
+		}
+	}
+	return nil
+}
+
+func (client *BaseClient) writeCall(buf *bytes.Buffer, name string, args []reflect.Value, options *InvokeOptions) (err error) {
 	simple := client.SimpleMode
 	if s, ok := options.SimpleMode.(bool); ok {
 		simple = s
@@ -430,37 +869,34 @@ func (client *BaseClient) doOutput(name string, args []reflect.Value, options *I
 	}
 	writer := NewWriter(buf, simple)
 	if err = writer.Stream.WriteByte(TagCall); err != nil {
-		return nil, err
+		return err
 	}
 	if err = writer.WriteString(name); err != nil {
-		return nil, err
+		return err
 	}
 	if args != nil && (len(args) > 0 || byref) {
 		writer.Reset()
 		if err = writer.WriteArray(args); err != nil {
-			return nil, err
+			return err
 		}
 		if byref {
 			if err = writer.WriteBool(true); err != nil {
-				return nil, err
+				return err
 			}
 		}
 	}
-	if err = writer.Stream.WriteByte(TagEnd); err != nil {
-		return nil, err
-	}
-	data = buf.Bytes()
-	n := len(client.filters)
-	for i := 0; i < n; i++ {
-		data = client.filters[i].OutputFilter(data, context)
+	return nil
+}
+
+func (client *BaseClient) doCodecInput(data []byte, args []reflect.Value, result []reflect.Value, context *ClientContext) error {
+	replies, err := client.decodeCodecResponse(data, context)
+	if err != nil {
+		return err
 	}
-	return data, nil
+	return client.setCodecReply(replies[0], args, result)
 }
 
 func (client *BaseClient) doIntput(data []byte, args []reflect.Value, options *InvokeOptions, result []reflect.Value, context *ClientContext) (err error) {
-	for i := len(client.filters) - 1; i >= 0; i-- {
-		data = client.filters[i].InputFilter(data, context)
-	}
 	resultMode := options.ResultMode
 	if last := len(data) - 1; data[last] == TagEnd {
 		if resultMode == Raw {
@@ -481,77 +917,19 @@ func (client *BaseClient) doIntput(data []byte, args []reflect.Value, options *I
 	for tag, err = istream.ReadByte(); err == nil && tag != TagEnd; tag, err = istream.ReadByte() {
 		switch tag {
 		case TagResult:
-			switch resultMode {
-			case Normal:
-				reader.Reset()
-				length := len(result)
-				if length == 1 {
-					err = reader.ReadValue(result[0])
-				} else if err = reader.CheckTag(TagList); err == nil {
-					var count int
-					if count, err = reader.ReadInteger(TagOpenbrace); err == nil {
-						r := make([]reflect.Value, count)
-						if count <= length {
-							for i := 0; i < count; i++ {
-								r[i] = result[i]
-							}
-						} else {
-							for i := 0; i < length; i++ {
-								r[i] = result[i]
-							}
-							for i := length; i < count; i++ {
-								var e interface{}
-								r[i] = reflect.ValueOf(&e).Elem()
-							}
-						}
-						err = reader.ReadArray(r)
-					}
-				}
-				if err != nil {
-					return err
-				}
-			case Serialized:
-				var buf []byte
-				if buf, err = reader.ReadRaw(); err != nil {
-					return err
-				}
-				if err = setResult(result[0], buf); err != nil {
-					return err
-				}
+			if err = readResult(reader, resultMode, result); err != nil {
+				return err
 			}
 		case TagArgument:
-			reader.Reset()
-			if err = reader.CheckTag(TagList); err == nil {
-				length := len(args)
-				var count int
-				if count, err = reader.ReadInteger(TagOpenbrace); err == nil {
-					a := make([]reflect.Value, count)
-					if count <= length {
-						for i := 0; i < count; i++ {
-							a[i] = args[i].Elem()
-						}
-					} else {
-						for i := 0; i < length; i++ {
-							a[i] = args[i].Elem()
-						}
-						for i := length; i < count; i++ {
-							var e interface{}
-							a[i] = reflect.ValueOf(&e).Elem()
-						}
-					}
-					err = reader.ReadArray(a)
-				}
-			}
-			if err != nil {
+			if err = readArguments(reader, args); err != nil {
 				return err
 			}
 		case TagError:
-			reader.Reset()
-			var e string
-			if e, err = reader.ReadString(); err == nil {
-				err = errors.New(e)
+			var e *RemoteError
+			if e, err = readError(reader, istream); err != nil {
+				return err
 			}
-			return err
+			return e
 		default:
 			return errors.New("Wrong Response: \r\n" + string(data))
 		}
@@ -573,7 +951,13 @@ func (client *BaseClient) createStub(stub interface{}, ns string) {
 		f := obj.Field(i)
 		ft := f.Type()
 		if ft.Kind() == reflect.Func {
-			f.Set(reflect.MakeFunc(ft, client.remoteMethod(ft, et.Field(i), ns)))
+			sf := et.Field(i)
+			name := getFuncName(&sf)
+			if ns != "" {
+				name = ns + "_" + name
+			}
+			options := &InvokeOptions{ByRef: getByRef(&sf), SimpleMode: getSimpleMode(&sf), ResultMode: getResultMode(&sf), Oneway: getOneway(&sf), Idempotent: getIdempotent(&sf)}
+			f.Set(reflect.MakeFunc(ft, client.remoteMethod(ft, name, options)))
 		} else if ft.Kind() == reflect.Ptr {
 			ft = ft.Elem()
 		}
@@ -601,13 +985,16 @@ func (client *BaseClient) createStub(stub interface{}, ns string) {
 	}
 }
 
-func (client *BaseClient) remoteMethod(t reflect.Type, sf reflect.StructField, ns string) func(in []reflect.Value) (out []reflect.Value) {
-	name := getFuncName(&sf)
-	if ns != "" {
-		name = ns + "_" + name
-	}
-	options := &InvokeOptions{ByRef: getByRef(&sf), SimpleMode: getSimpleMode(&sf), ResultMode: getResultMode(&sf)}
+func (client *BaseClient) remoteMethod(t reflect.Type, name string, options *InvokeOptions) func(in []reflect.Value) (out []reflect.Value) {
+	hasContext := t.NumIn() > 0 && t.In(0) == contextType
 	return func(in []reflect.Value) (out []reflect.Value) {
+		ctx := backgroundContext
+		if hasContext {
+			if c, ok := in[0].Interface().(context.Context); ok {
+				ctx = c
+			}
+			in = in[1:]
+		}
 		inlen := len(in)
 		varlen := 0
 		argc := inlen
@@ -635,7 +1022,7 @@ func (client *BaseClient) remoteMethod(t reflect.Type, sf reflect.StructField, n
 		switch numout {
 		case 0:
 			var result interface{}
-			err := <-client.invoke(name, args, options, []reflect.Value{reflect.ValueOf(&result).Elem()})
+			err := <-client.invoke(ctx, name, args, options, []reflect.Value{reflect.ValueOf(&result).Elem()})
 			if err == nil {
 				return out
 			}
@@ -645,22 +1032,22 @@ func (client *BaseClient) remoteMethod(t reflect.Type, sf reflect.StructField, n
 			if rt0.Kind() == reflect.Chan {
 				if rt0.Elem().Kind() == reflect.Interface && rt0.Elem().Name() == "error" {
 					var result chan interface{}
-					err := client.invoke(name, args, options, []reflect.Value{reflect.ValueOf(&result).Elem()})
+					err := client.invoke(ctx, name, args, options, []reflect.Value{reflect.ValueOf(&result).Elem()})
 					out[0] = reflect.ValueOf(&err).Elem()
 					return out
 				}
 				out[0] = reflect.New(rt0).Elem()
-				client.invoke(name, args, options, out)
+				client.invoke(ctx, name, args, options, out)
 				return out
 			}
 			if rt0.Kind() == reflect.Interface && rt0.Name() == "error" {
 				var result interface{}
-				err := <-client.invoke(name, args, options, []reflect.Value{reflect.ValueOf(&result).Elem()})
+				err := <-client.invoke(ctx, name, args, options, []reflect.Value{reflect.ValueOf(&result).Elem()})
 				out[0] = reflect.ValueOf(&err).Elem()
 				return out
 			}
 			out[0] = reflect.New(rt0).Elem()
-			err := <-client.invoke(name, args, options, out)
+			err := <-client.invoke(ctx, name, args, options, out)
 			if err == nil {
 				return out
 			}
@@ -674,22 +1061,22 @@ func (client *BaseClient) remoteMethod(t reflect.Type, sf reflect.StructField, n
 			if rtlast.Kind() == reflect.Chan &&
 				rtlast.Elem().Kind() == reflect.Interface &&
 				rtlast.Elem().Name() == "error" {
-				err := client.invoke(name, args, options, out[:last])
+				err := client.invoke(ctx, name, args, options, out[:last])
 				out[last] = reflect.ValueOf(&err).Elem()
 				return out
 			}
 			if rtlast.Kind() == reflect.Interface &&
 				rtlast.Name() == "error" {
-				err := <-client.invoke(name, args, options, out[:last])
+				err := <-client.invoke(ctx, name, args, options, out[:last])
 				out[last] = reflect.ValueOf(&err).Elem()
 				return out
 			}
 			out[last] = reflect.New(t.Out(last)).Elem()
 			if t.Out(0).Kind() == reflect.Chan {
-				client.invoke(name, args, options, out)
+				client.invoke(ctx, name, args, options, out)
 				return out
 			}
-			err := <-client.invoke(name, args, options, out)
+			err := <-client.invoke(ctx, name, args, options, out)
 			if err == nil {
 				return out
 			}
@@ -707,6 +1094,8 @@ func RegisterClientFactory(scheme string, newClient func(string) Client) {
 
 // private functions
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 func isStructPointer(p interface{}) bool {
 	v := reflect.ValueOf(p)
 	if !v.IsValid() || v.IsNil() {
@@ -727,6 +1116,80 @@ func checkRefArgs(args []reflect.Value) bool {
 	return true
 }
 
+func readResult(reader *Reader, resultMode ResultMode, result []reflect.Value) (err error) {
+	switch resultMode {
+	case Normal:
+		reader.Reset()
+		length := len(result)
+		if length == 1 {
+			return reader.ReadValue(result[0])
+		}
+		if err = reader.CheckTag(TagList); err != nil {
+			return err
+		}
+		var count int
+		if count, err = reader.ReadInteger(TagOpenbrace); err != nil {
+			return err
+		}
+		r := make([]reflect.Value, count)
+		if count <= length {
+			for i := 0; i < count; i++ {
+				r[i] = result[i]
+			}
+		} else {
+			for i := 0; i < length; i++ {
+				r[i] = result[i]
+			}
+			for i := length; i < count; i++ {
+				var e interface{}
+				r[i] = reflect.ValueOf(&e).Elem()
+			}
+		}
+		return reader.ReadArray(r)
+	case Serialized:
+		var buf []byte
+		if buf, err = reader.ReadRaw(); err != nil {
+			return err
+		}
+		return setResult(result[0], buf)
+	}
+	return nil
+}
+
+func readArguments(reader *Reader, args []reflect.Value) (err error) {
+	reader.Reset()
+	if err = reader.CheckTag(TagList); err != nil {
+		return err
+	}
+	length := len(args)
+	var count int
+	if count, err = reader.ReadInteger(TagOpenbrace); err != nil {
+		return err
+	}
+	a := make([]reflect.Value, count)
+	if count <= length {
+		for i := 0; i < count; i++ {
+			a[i] = args[i].Elem()
+		}
+	} else {
+		for i := 0; i < length; i++ {
+			a[i] = args[i].Elem()
+		}
+		for i := length; i < count; i++ {
+			var e interface{}
+			a[i] = reflect.ValueOf(&e).Elem()
+		}
+	}
+	return reader.ReadArray(a)
+}
+
+func writeHeaders(buf *bytes.Buffer, headers map[string]interface{}) (err error) {
+	if err = buf.WriteByte(TagHeader); err != nil {
+		return err
+	}
+	return NewWriter(buf, true).Serialize(headers)
+}
+
 func setResult(result reflect.Value, buf []byte) error {
 	switch result.Interface().(type) {
 	case []byte, interface{}:
@@ -775,6 +1238,28 @@ func getSimpleMode(sf *reflect.StructField) interface{} {
 	return nil
 }
 
+func getOneway(sf *reflect.StructField) bool {
+	keys := []string{"oneway", "Oneway"}
+	for i := range keys {
+		switch strings.ToLower(sf.Tag.Get(keys[i])) {
+		case "true", "t", "1":
+			return true
+		}
+	}
+	return false
+}
+
+func getIdempotent(sf *reflect.StructField) bool {
+	keys := []string{"idempotent", "Idempotent"}
+	for i := range keys {
+		switch strings.ToLower(sf.Tag.Get(keys[i])) {
+		case "true", "t", "1":
+			return true
+		}
+	}
+	return false
+}
+
 func getResultMode(sf *reflect.StructField) ResultMode {
 	keys := []string{"result", "Result", "resultMode", "ResultMode"}
 	for i := range keys {
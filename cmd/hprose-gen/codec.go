@@ -0,0 +1,222 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * cmd/hprose-gen/codec.go                                *
+ *                                                        *
+ * hprose codec generator for Go.                         *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type structField struct {
+	name    string // the field name in Go
+	alias   string // the field name in hprose
+	isPtr   bool
+	marshal bool   // the pointer of the field type implements HproseMarshaler
+	write   string // the typed write method, the same as Writer.Serialize
+}
+
+// writeMethods are the Writer methods which Writer.Serialize uses for
+// the builtin types.
+var writeMethods = map[string]string{
+	"int":     "WriteInt64(int64(%s))",
+	"int8":    "WriteInt64(int64(%s))",
+	"int16":   "WriteInt64(int64(%s))",
+	"int32":   "WriteInt64(int64(%s))",
+	"rune":    "WriteInt64(int64(%s))",
+	"int64":   "WriteInt64(%s)",
+	"uint":    "WriteUint64(uint64(%s))",
+	"uint8":   "WriteUint64(uint64(%s))",
+	"byte":    "WriteUint64(uint64(%s))",
+	"uint16":  "WriteUint64(uint64(%s))",
+	"uint32":  "WriteUint64(uint64(%s))",
+	"uint64":  "WriteUint64(%s)",
+	"float32": "WriteFloat64(float64(%s))",
+	"float64": "WriteFloat64(%s)",
+	"bool":    "WriteBool(%s)",
+	"string":  "WriteStringWithRef(%s)",
+}
+
+type structCodec struct {
+	name   string
+	fields []*structField
+}
+
+func (g *generator) generateCodecs(names []string, tag string) error {
+	codecs := make([]*structCodec, 0, len(names))
+	for _, name := range names {
+		c, err := g.parseStruct(name, tag)
+		if err != nil {
+			return err
+		}
+		codecs = append(codecs, c)
+	}
+	for _, c := range codecs {
+		if len(c.fields) > 0 {
+			g.imports["strings"] = "strings"
+		}
+		g.generateWriteTo(c)
+		g.generateReadFrom(c)
+	}
+	return nil
+}
+
+// parseStruct gets the fields in the same order and with the same names
+// as the reflective encoder of hprose.Writer.
+func (g *generator) parseStruct(name string, tag string) (*structCodec, error) {
+	spec := findType(g.pkg, name)
+	if spec == nil {
+		return nil, errors.New("type " + name + " is not found")
+	}
+	st, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil, errors.New(name + " must be a struct")
+	}
+	marshalers := g.marshalers()
+	c := &structCodec{name: name}
+	for _, field := range st.Fields.List {
+		if len(field.Names) == 0 {
+			return nil, errors.New(name + ": embedded field " + types.ExprString(field.Type) + " is not supported")
+		}
+		if !serializable(field.Type) {
+			continue
+		}
+		_, isPtr := field.Type.(*ast.StarExpr)
+		marshal, write := false, ""
+		if ident, ok := field.Type.(*ast.Ident); ok {
+			marshal = marshalers[ident.Name]
+			if findType(g.pkg, ident.Name) == nil {
+				write = writeMethods[ident.Name]
+			}
+		}
+		for _, n := range field.Names {
+			if !n.IsExported() {
+				continue
+			}
+			alias := fieldAlias(n.Name, field.Tag, tag)
+			if alias == "-" {
+				continue
+			}
+			c.fields = append(c.fields, &structField{
+				name:    n.Name,
+				alias:   alias,
+				isPtr:   isPtr,
+				marshal: marshal,
+				write:   write,
+			})
+		}
+	}
+	return c, nil
+}
+
+// marshalers returns the names of the types in the package whose pointer
+// has the MarshalHprose method.
+func (g *generator) marshalers() map[string]bool {
+	marshalers := make(map[string]bool)
+	for _, file := range g.files {
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || fd.Name.Name != "MarshalHprose" {
+				continue
+			}
+			if _, ok := fd.Recv.List[0].Type.(*ast.StarExpr); ok {
+				marshalers[receiverName(fd.Recv)] = true
+			}
+		}
+	}
+	return marshalers
+}
+
+func serializable(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.ChanType, *ast.FuncType:
+		return false
+	case *ast.SelectorExpr:
+		return types.ExprString(t) != "unsafe.Pointer"
+	}
+	return true
+}
+
+func fieldAlias(name string, lit *ast.BasicLit, tag string) string {
+	alias := ""
+	if tag != "" && lit != nil {
+		if s, err := strconv.Unquote(lit.Value); err == nil {
+			alias = strings.SplitN(reflect.StructTag(s).Get(tag), ",", 2)[0]
+			alias = strings.TrimSpace(strings.SplitN(alias, ">", 2)[0])
+		}
+	}
+	if alias == "" {
+		alias = strings.ToLower(name[:1]) + name[1:]
+	}
+	return alias
+}
+
+func (g *generator) generateWriteTo(c *structCodec) {
+	g.printf("\n// WriteTo writes the fields of %s to writer\n", c.name)
+	g.printf("func (v *%s) WriteTo(writer *hprose.Writer) (err error) {\n", c.name)
+	for _, f := range c.fields {
+		value := "v." + f.name
+		if f.marshal {
+			value = "&" + value
+		}
+		if f.isPtr {
+			g.printf("\tif v.%s == nil {\n\t\terr = writer.WriteNull()\n\t} else {\n", f.name)
+			g.printf("\t\terr = writer.Serialize(%s)\n\t}\n", value)
+			g.printf("\tif err != nil {\n\t\treturn\n\t}\n")
+		} else {
+			write := "Serialize(" + value + ")"
+			if f.write != "" {
+				write = fmt.Sprintf(f.write, value)
+			}
+			g.printf("\tif err = writer.%s; err != nil {\n\t\treturn\n\t}\n", write)
+		}
+	}
+	g.printf("\treturn nil\n}\n")
+}
+
+func (g *generator) generateReadFrom(c *structCodec) {
+	g.printf("\n// ReadFrom reads the fields of %s from reader\n", c.name)
+	g.printf("func (v *%s) ReadFrom(reader *hprose.Reader) (err error) {\n", c.name)
+	g.printf("\tfor _, name := range reader.Fields() {\n")
+	if len(c.fields) > 0 {
+		// the later field wins just like the index cache of hprose.Reader
+		cases := make(map[string]*structField)
+		order := make([]string, 0, len(c.fields))
+		for _, f := range c.fields {
+			key := strings.ToLower(f.alias)
+			if _, ok := cases[key]; !ok {
+				order = append(order, key)
+			}
+			cases[key] = f
+		}
+		g.printf("\t\tswitch strings.ToLower(name) {\n")
+		for _, key := range order {
+			g.printf("\t\tcase %q:\n\t\t\terr = reader.Unserialize(&v.%s)\n", key, cases[key].name)
+		}
+		g.printf("\t\tdefault:\n\t\t\tvar value interface{}\n\t\t\terr = reader.Unserialize(&value)\n\t\t}\n")
+	} else {
+		g.printf("\t\tvar value interface{}\n\t\terr = reader.Unserialize(&value)\n")
+	}
+	g.printf("\t\tif err != nil {\n\t\t\treturn\n\t\t}\n\t}\n\treturn\n}\n")
+}
@@ -0,0 +1,757 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * cmd/hprose-gen/generator.go                            *
+ *                                                        *
+ * hprose code generator for Go.                          *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type param struct {
+	name     string
+	typ      string
+	variadic bool
+	original string // the name in the source, "" if it is unnamed
+}
+
+type method struct {
+	name       string
+	remoteName string
+	simple     string // "true", "false" or ""
+	byref      string // "true", "false" or ""
+	result     string // "Normal", "Serialized", "Raw", "RawWithEndTag"
+	idempotent bool
+	params     []param
+	results    []string
+	hasContext bool
+	hasError   bool
+}
+
+type service struct {
+	name        string
+	isInterface bool
+	methods     []*method
+}
+
+type generator struct {
+	pkg     *ast.Package
+	files   []*ast.File
+	imports map[string]string
+	buf     bytes.Buffer
+}
+
+var resultModes = map[string]string{
+	"normal":        "Normal",
+	"serialized":    "Serialized",
+	"raw":           "Raw",
+	"rawwithendtag": "RawWithEndTag",
+}
+
+var reservedNames = map[string]bool{
+	"client": true, "writer": true, "reader": true, "err": true,
+	"hprose": true, "context": true, "options": true,
+}
+
+// generate generates the services of names, or the codecs of names
+// when codec is true.
+func generate(dir string, outName string, names []string, hprosePath string, codec bool, tag string) ([]byte, error) {
+	fset := token.NewFileSet()
+	filter := func(fi os.FileInfo) bool { return fi.Name() != outName }
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{imports: make(map[string]string)}
+	for _, pkg := range pkgs {
+		if findType(pkg, names[0]) != nil {
+			g.pkg = pkg
+			break
+		}
+	}
+	if g.pkg == nil {
+		return nil, errors.New("type " + names[0] + " is not found in " + dir)
+	}
+	filenames := make([]string, 0, len(g.pkg.Files))
+	for filename := range g.pkg.Files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		g.files = append(g.files, g.pkg.Files[filename])
+	}
+	if codec {
+		err = g.generateCodecs(names, tag)
+	} else {
+		err = g.generateServices(names)
+	}
+	if err != nil {
+		return nil, err
+	}
+	body := g.buf.String()
+	g.buf.Reset()
+	g.printf("// Code generated by hprose-gen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.pkg.Name)
+	if strings.Contains(body, "context.") {
+		g.imports["context"] = "context"
+	}
+	g.imports["hprose"] = hprosePath
+	std := make([]string, 0, len(g.imports))
+	other := make([]string, 0, len(g.imports))
+	for name, p := range g.imports {
+		spec := strconv.Quote(p)
+		if name != path.Base(p) {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	g.printf("import (\n")
+	for _, spec := range std {
+		g.printf("\t%s\n", spec)
+	}
+	g.printf("\n")
+	for _, spec := range other {
+		g.printf("\t%s\n", spec)
+	}
+	g.printf(")\n")
+	g.buf.WriteString(body)
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return g.buf.Bytes(), err
+	}
+	return src, nil
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func findType(pkg *ast.Package, name string) (spec *ast.TypeSpec) {
+	for _, file := range pkg.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if ts, ok := n.(*ast.TypeSpec); ok && ts.Name.Name == name {
+				spec = ts
+			}
+			return spec == nil
+		})
+		if spec != nil {
+			return spec
+		}
+	}
+	return nil
+}
+
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := path.Base(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	return imports
+}
+
+func fileOf(pkg *ast.Package, node ast.Node) *ast.File {
+	for _, file := range pkg.Files {
+		if file.Pos() <= node.Pos() && node.End() <= file.End() {
+			return file
+		}
+	}
+	return nil
+}
+
+// useImports records the packages which are used in the type expression.
+func (g *generator) useImports(expr ast.Expr, file *ast.File) {
+	imports := fileImports(file)
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok {
+				if p, ok := imports[x.Name]; ok {
+					g.imports[x.Name] = p
+				}
+			}
+			return false
+		}
+		return true
+	})
+}
+
+func (g *generator) parseService(name string) (*service, error) {
+	spec := findType(g.pkg, name)
+	if spec == nil {
+		return nil, errors.New("type " + name + " is not found")
+	}
+	s := &service{name: name}
+	switch t := spec.Type.(type) {
+	case *ast.InterfaceType:
+		s.isInterface = true
+		if err := g.parseInterface(s, t, fileOf(g.pkg, spec)); err != nil {
+			return nil, err
+		}
+	case *ast.StructType:
+		for _, file := range g.files {
+			for _, decl := range file.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Recv == nil || !fd.Name.IsExported() || receiverName(fd.Recv) != name {
+					continue
+				}
+				m, err := g.parseMethod(fd.Name.Name, fd.Type, fd.Doc, file)
+				if err != nil {
+					return nil, err
+				}
+				s.methods = append(s.methods, m)
+			}
+		}
+	default:
+		return nil, errors.New(name + " must be an interface or struct")
+	}
+	return s, nil
+}
+
+func (g *generator) parseInterface(s *service, t *ast.InterfaceType, file *ast.File) error {
+	for _, field := range t.Methods.List {
+		switch ft := field.Type.(type) {
+		case *ast.FuncType:
+			for _, n := range field.Names {
+				m, err := g.parseMethod(n.Name, ft, field.Doc, file)
+				if err != nil {
+					return err
+				}
+				s.methods = append(s.methods, m)
+			}
+		case *ast.Ident:
+			spec := findType(g.pkg, ft.Name)
+			if spec == nil {
+				return errors.New("embedded interface " + ft.Name + " is not found")
+			}
+			it, ok := spec.Type.(*ast.InterfaceType)
+			if !ok {
+				return errors.New(ft.Name + " is not an interface")
+			}
+			if err := g.parseInterface(s, it, fileOf(g.pkg, spec)); err != nil {
+				return err
+			}
+		default:
+			return errors.New("embedded interface " + types.ExprString(ft) + " is not supported")
+		}
+	}
+	return nil
+}
+
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) != 1 {
+		return ""
+	}
+	t := recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	if ident, ok := t.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+func (g *generator) parseMethod(name string, ft *ast.FuncType, doc *ast.CommentGroup, file *ast.File) (*method, error) {
+	m := &method{name: name, remoteName: name, result: "Normal"}
+	if err := m.parseOptions(doc); err != nil {
+		return nil, fmt.Errorf("method %s: %v", name, err)
+	}
+	i := 0
+	for _, field := range ft.Params.List {
+		g.useImports(field.Type, file)
+		p := param{typ: types.ExprString(field.Type)}
+		if ellipsis, ok := field.Type.(*ast.Ellipsis); ok {
+			p.typ = types.ExprString(ellipsis.Elt)
+			p.variadic = true
+		}
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{{Name: "_"}}
+		}
+		for _, n := range names {
+			p.name = n.Name
+			p.original = ""
+			if n.Name != "_" {
+				p.original = n.Name
+			}
+			if p.name == "_" || reservedNames[p.name] || strings.HasPrefix(p.name, "r") && isNumber(p.name[1:]) {
+				p.name = "a" + strconv.Itoa(i)
+			}
+			m.params = append(m.params, p)
+			i++
+		}
+	}
+	if len(m.params) > 0 && m.params[0].typ == "context.Context" {
+		m.hasContext = true
+	}
+	if ft.Results != nil {
+		for _, field := range ft.Results.List {
+			g.useImports(field.Type, file)
+			typ := types.ExprString(field.Type)
+			if _, ok := field.Type.(*ast.ChanType); ok {
+				return nil, fmt.Errorf("method %s: chan result is not supported", name)
+			}
+			n := len(field.Names)
+			if n == 0 {
+				n = 1
+			}
+			for j := 0; j < n; j++ {
+				m.results = append(m.results, typ)
+			}
+		}
+	}
+	if n := len(m.results); n > 0 && m.results[n-1] == "error" {
+		m.hasError = true
+		m.results = m.results[:n-1]
+	}
+	if (m.byref == "true" || m.result != "Normal") && len(m.results) > 1 {
+		return nil, fmt.Errorf("method %s: byref or result mode method can't have more than one result", name)
+	}
+	return m, nil
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+func (m *method) parseOptions(doc *ast.CommentGroup) error {
+	if doc == nil {
+		return nil
+	}
+	for _, c := range doc.List {
+		line := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if !strings.HasPrefix(line, "hprose:") {
+			continue
+		}
+		fields := strings.FieldsFunc(line[len("hprose:"):], func(r rune) bool {
+			return r == ' ' || r == ',' || r == '\t'
+		})
+		for _, field := range fields {
+			kv := strings.SplitN(field, "=", 2)
+			value := "true"
+			if len(kv) == 2 {
+				value = kv[1]
+			}
+			switch kv[0] {
+			case "name":
+				m.remoteName = value
+			case "simple":
+				m.simple = value
+			case "byref":
+				m.byref = value
+			case "idempotent":
+				m.idempotent = value == "true"
+			case "result":
+				mode, ok := resultModes[strings.ToLower(value)]
+				if !ok {
+					return errors.New("unknown result mode " + value)
+				}
+				m.result = mode
+			default:
+				return errors.New("unknown option " + kv[0])
+			}
+			if kv[0] == "simple" || kv[0] == "byref" || kv[0] == "idempotent" {
+				if _, err := strconv.ParseBool(value); err != nil {
+					return errors.New("wrong option " + field)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (m *method) signature(withNames bool) string {
+	params := make([]string, len(m.params))
+	for i, p := range m.params {
+		typ := p.typ
+		if p.variadic {
+			typ = "..." + typ
+		}
+		if withNames {
+			params[i] = p.name + " " + typ
+		} else {
+			params[i] = typ
+		}
+	}
+	results := make([]string, 0, len(m.results)+1)
+	for i, r := range m.results {
+		if withNames {
+			results = append(results, "r"+strconv.Itoa(i)+" "+r)
+		} else {
+			results = append(results, r)
+		}
+	}
+	if m.hasError {
+		if withNames {
+			results = append(results, "err error")
+		} else {
+			results = append(results, "error")
+		}
+	}
+	s := "(" + strings.Join(params, ", ") + ")"
+	switch {
+	case len(results) == 0:
+	case len(results) == 1 && !withNames:
+		s += " " + results[0]
+	default:
+		s += " (" + strings.Join(results, ", ") + ")"
+	}
+	return s
+}
+
+// paramNames returns the quoted names of the parameters for the named
+// params of JSON-RPC, or nil if any parameter is unnamed or variadic.
+func (m *method) paramNames() []string {
+	params := m.params
+	if m.hasContext {
+		params = params[1:]
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	names := make([]string, len(params))
+	for i, p := range params {
+		if p.original == "" || p.variadic {
+			return nil
+		}
+		names[i] = strconv.Quote(p.original)
+	}
+	return names
+}
+
+// proxyField returns the name of the func field of the proxy, it is
+// unexported, so it never conflicts with the methods of the interface.
+func (m *method) proxyField() string {
+	return strings.ToLower(m.name[:1]) + m.name[1:] + "Func"
+}
+
+func (m *method) tags() string {
+	tags := make([]string, 0, 5)
+	if m.remoteName != m.name {
+		tags = append(tags, fmt.Sprintf("name:%q", m.remoteName))
+	}
+	if m.byref != "" {
+		tags = append(tags, fmt.Sprintf("byref:%q", m.byref))
+	}
+	if m.simple != "" {
+		tags = append(tags, fmt.Sprintf("simple:%q", m.simple))
+	}
+	if m.result != "Normal" {
+		tags = append(tags, fmt.Sprintf("result:%q", strings.ToLower(m.result)))
+	}
+	if m.idempotent {
+		tags = append(tags, `idempotent:"true"`)
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return " `" + strings.Join(tags, " ") + "`"
+}
+
+func (m *method) invokeOptions() string {
+	opts := make([]string, 0, 4)
+	if m.byref != "" {
+		opts = append(opts, "ByRef: "+m.byref)
+	}
+	if m.simple != "" {
+		opts = append(opts, "SimpleMode: "+m.simple)
+	}
+	if m.result != "Normal" {
+		opts = append(opts, "ResultMode: hprose."+m.result)
+	}
+	if m.idempotent {
+		opts = append(opts, "Idempotent: true")
+	}
+	if len(opts) == 0 {
+		return "nil"
+	}
+	return "&hprose.InvokeOptions{" + strings.Join(opts, ", ") + "}"
+}
+
+func (g *generator) generateServices(names []string) error {
+	services := make([]*service, 0, len(names))
+	for _, name := range names {
+		s, err := g.parseService(name)
+		if err != nil {
+			return err
+		}
+		services = append(services, s)
+	}
+	for _, s := range services {
+		g.generateService(s)
+	}
+	return nil
+}
+
+func (g *generator) generateService(s *service) {
+	g.generateOptions(s)
+	g.generateStub(s)
+	g.generateProxy(s)
+	g.generateClient(s)
+	g.generateRegister(s)
+}
+
+func (g *generator) generateOptions(s *service) {
+	if !s.isInterface {
+		return
+	}
+	g.printf("\n// %sOptions is the InterfaceOptions of %s\n", s.name, s.name)
+	g.printf("var %sOptions = hprose.InterfaceOptions{\n", s.name)
+	for _, m := range s.methods {
+		opts := make([]string, 0, 5)
+		if m.remoteName != m.name {
+			opts = append(opts, fmt.Sprintf("Name: %q", m.remoteName))
+		}
+		if m.byref != "" {
+			opts = append(opts, "ByRef: "+m.byref)
+		}
+		if m.simple != "" {
+			opts = append(opts, "SimpleMode: "+m.simple)
+		}
+		if m.result != "Normal" {
+			opts = append(opts, "ResultMode: hprose."+m.result)
+		}
+		if m.idempotent {
+			opts = append(opts, "Idempotent: true")
+		}
+		if len(opts) > 0 {
+			g.printf("\t%q: {%s},\n", m.name, strings.Join(opts, ", "))
+		}
+	}
+	g.printf("}\n")
+}
+
+func (g *generator) generateStub(s *service) {
+	g.printf("\n// %sStub is the struct stub of %s for UseService\n", s.name, s.name)
+	g.printf("type %sStub struct {\n", s.name)
+	for _, m := range s.methods {
+		g.printf("\t%s func%s%s\n", m.name, m.signature(false), m.tags())
+	}
+	g.printf("}\n")
+}
+
+// generateProxy generates the proxy of the interface which is created by
+// UseService, and registers it by hprose.RegisterProxy.
+func (g *generator) generateProxy(s *service) {
+	if !s.isInterface {
+		return
+	}
+	g.printf("\n// %sProxy implements %s by the remote methods of hprose.Stub\n", s.name, s.name)
+	g.printf("type %sProxy struct {\n", s.name)
+	for _, m := range s.methods {
+		g.printf("\t%s func%s\n", m.proxyField(), m.signature(false))
+	}
+	g.printf("}\n")
+	for _, m := range s.methods {
+		args := make([]string, len(m.params))
+		for i, p := range m.params {
+			args[i] = p.name
+			if p.variadic {
+				args[i] += "..."
+			}
+		}
+		call := "proxy." + m.proxyField() + "(" + strings.Join(args, ", ") + ")"
+		g.printf("\n// %s invokes the remote method %s\n", m.name, m.remoteName)
+		g.printf("func (proxy *%sProxy) %s%s {\n", s.name, m.name, m.signature(true))
+		if len(m.results) > 0 || m.hasError {
+			g.printf("\treturn %s\n}\n", call)
+		} else {
+			g.printf("\t%s\n}\n", call)
+		}
+	}
+	g.printf("\nfunc init() {\n")
+	g.printf("\thprose.RegisterProxy((*%s)(nil), func(stub *hprose.Stub) interface{} {\n", s.name)
+	g.printf("\t\treturn &%sProxy{\n", s.name)
+	for _, m := range s.methods {
+		g.printf("\t\t\t%s: stub.Func(%q).(func%s),\n", m.proxyField(), m.name, m.signature(false))
+	}
+	g.printf("\t\t}\n\t})\n}\n")
+}
+
+func (g *generator) generateClient(s *service) {
+	g.printf("\n// %sClient calls the methods of %s without reflection,\n", s.name, s.name)
+	g.printf("// or by the struct stub when the client has a codec\n")
+	g.printf("type %sClient struct {\n\tClient hprose.Client\n\tstub   *%sStub\n}\n", s.name, s.name)
+	g.printf("\n// New%sClient is the constructor of %sClient\n", s.name, s.name)
+	g.printf("func New%sClient(client hprose.Client) *%sClient {\n", s.name, s.name)
+	g.printf("\tc := &%sClient{Client: client}\n", s.name)
+	g.printf("\tclient.UseService(&c.stub)\n")
+	g.printf("\treturn c\n}\n")
+	for _, m := range s.methods {
+		g.generateMethod(s, m)
+	}
+}
+
+func (g *generator) generateMethod(s *service, m *method) {
+	ctx := "context.Background()"
+	params := m.params
+	if m.hasContext {
+		ctx = params[0].name
+		params = params[1:]
+	}
+	g.printf("\n// %s invokes the remote method %s\n", m.name, m.remoteName)
+	g.printf("func (client *%sClient) %s%s {\n", s.name, m.name, m.signature(true))
+	g.generateCodecFallback(m)
+	if m.hasError {
+		g.printf("\terr = ")
+	} else {
+		g.printf("\terr := ")
+	}
+	if m.byref == "true" || m.result != "Normal" {
+		g.generateInvoke(m, ctx, params)
+	} else {
+		g.generateInvokeWith(m, ctx, params)
+	}
+	if !m.hasError {
+		g.printf("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+	}
+	if len(m.results) > 0 || m.hasError {
+		g.printf("\treturn\n")
+	}
+	g.printf("}\n")
+}
+
+// generateCodecFallback calls the struct stub when the client has a codec,
+// because InvokeWith writes and reads the hprose format only.
+func (g *generator) generateCodecFallback(m *method) {
+	args := make([]string, len(m.params))
+	for i, p := range m.params {
+		args[i] = p.name
+		if p.variadic {
+			args[i] += "..."
+		}
+	}
+	call := "client.stub." + m.name + "(" + strings.Join(args, ", ") + ")"
+	g.printf("\tif client.Client.Codec() != nil {\n")
+	if len(m.results) > 0 || m.hasError {
+		g.printf("\t\treturn %s\n", call)
+	} else {
+		g.printf("\t\t%s\n\t\treturn\n", call)
+	}
+	g.printf("\t}\n")
+}
+
+// generateInvoke uses the reflective Invoke for the byref
+// and result mode methods.
+func (g *generator) generateInvoke(m *method, ctx string, params []param) {
+	args := make([]string, 0, len(params))
+	variadic := ""
+	for _, p := range params {
+		if p.variadic {
+			variadic = p.name
+		} else {
+			args = append(args, p.name)
+		}
+	}
+	result := "new(interface{})"
+	if len(m.results) == 1 {
+		result = "&r0"
+	}
+	g.printf("func() error {\n")
+	g.printf("\t\targs := []interface{}{%s}\n", strings.Join(args, ", "))
+	if variadic != "" {
+		g.printf("\t\tfor _, arg := range %s {\n\t\t\targs = append(args, arg)\n\t\t}\n", variadic)
+	}
+	g.printf("\t\treturn <-client.Client.InvokeContext(%s, %q, args, %s, %s)\n", ctx, m.remoteName, m.invokeOptions(), result)
+	g.printf("\t}()\n")
+}
+
+func (g *generator) generateInvokeWith(m *method, ctx string, params []param) {
+	g.printf("client.Client.InvokeWith(%s, %q, %s, ", ctx, m.remoteName, m.invokeOptions())
+	if len(params) == 0 {
+		g.printf("nil, ")
+	} else {
+		count := strconv.Itoa(len(params))
+		if last := params[len(params)-1]; last.variadic {
+			count = "len(" + last.name + ")"
+			if len(params) > 1 {
+				count = fmt.Sprintf("%d+len(%s)", len(params)-1, last.name)
+			}
+		}
+		g.printf("func(writer *hprose.Writer) error {\n")
+		g.printf("\t\tif err := writer.WriteListHeader(%s); err != nil {\n\t\t\treturn err\n\t\t}\n", count)
+		for _, p := range params {
+			if p.variadic {
+				g.printf("\t\tfor _, arg := range %s {\n", p.name)
+				g.printf("\t\t\tif err := writer.Serialize(arg); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n\t\t}\n")
+			} else {
+				g.printf("\t\tif err := writer.Serialize(%s); err != nil {\n\t\t\treturn err\n\t\t}\n", p.name)
+			}
+		}
+		g.printf("\t\treturn writer.WriteListFooter()\n\t}, ")
+	}
+	switch len(m.results) {
+	case 0:
+		g.printf("nil)\n")
+	case 1:
+		g.printf("func(reader *hprose.Reader) error {\n\t\treturn reader.Unserialize(&r0)\n\t})\n")
+	default:
+		g.printf("func(reader *hprose.Reader) error {\n")
+		g.printf("\t\tif _, err := reader.ReadListHeader(); err != nil {\n\t\t\treturn err\n\t\t}\n")
+		for i := range m.results {
+			g.printf("\t\tif err := reader.Unserialize(&r%d); err != nil {\n\t\t\treturn err\n\t\t}\n", i)
+		}
+		g.printf("\t\treturn reader.ReadListFooter()\n\t})\n")
+	}
+}
+
+func (g *generator) generateRegister(s *service) {
+	impl := s.name
+	if !s.isInterface {
+		impl = "*" + s.name
+	}
+	g.printf("\n// Register%s publish the methods of %s to methods\n", s.name, s.name)
+	g.printf("func Register%s(methods *hprose.Methods, impl %s) {\n", s.name, impl)
+	for _, m := range s.methods {
+		opts := ""
+		if m.result != "Normal" {
+			opts += ", hprose." + m.result
+		}
+		if m.simple != "" {
+			opts += ", " + m.simple
+		}
+		if names := m.paramNames(); names != nil {
+			opts += ", hprose.ParamNames{" + strings.Join(names, ", ") + "}"
+		}
+		g.printf("\tmethods.AddFunction(%q, impl.%s%s)\n", m.remoteName, m.name, opts)
+	}
+	g.printf("}\n")
+}
@@ -0,0 +1,101 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * cmd/hprose-gen/main.go                                 *
+ *                                                        *
+ * hprose code generator for Go.                          *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+// hprose-gen generates the code for the hprose service interface or struct.
+//
+// Usage:
+//
+//	hprose-gen -type=Calculator [-output=calculator_hprose.go] [-hprose=github.com/hprose/hprose-go] [dir]
+//
+// or with go generate:
+//
+//	//go:generate hprose-gen -type=Calculator
+//
+// For the type T, it generates:
+//
+//	TStub            the struct stub for client.UseService
+//	TClient          the call wrappers which use Writer and Reader directly
+//	RegisterT        the function to publish the methods to hprose.Methods
+//	TOptions         the InterfaceOptions table, only for interface
+//
+// The options of the method are set in its doc comment, for example:
+//
+//	// hprose:name=hi simple byref result=raw idempotent
+//
+// With -codec, the types must be structs, and hprose-gen generates the
+// WriteTo and ReadFrom methods which are used by hprose.Writer and
+// hprose.Reader instead of reflection:
+//
+//	//go:generate hprose-gen -codec -type=User,Order
+//
+// The -tag flag must be the same as the tag which is registered to
+// hprose.ClassManager for the types.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames  = flag.String("type", "", "comma-separated list of interface or struct names; must be set")
+	output     = flag.String("output", "", "output file name; default is <type>_hprose.go")
+	hprosePath = flag.String("hprose", "github.com/hprose/hprose-go", "import path of hprose package")
+	codec      = flag.Bool("codec", false, "generate WriteTo and ReadFrom for the struct types")
+	tag        = flag.String("tag", "", "struct tag of the field alias for -codec")
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage of hprose-gen:")
+	fmt.Fprintln(os.Stderr, "\thprose-gen -type=T [flags] [directory]")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	flag.PrintDefaults()
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+	filename := *output
+	if filename == "" {
+		filename = strings.ToLower(names[0]) + "_hprose.go"
+	}
+	filename = filepath.Join(dir, filename)
+	src, err := generate(dir, filepath.Base(filename), names, *hprosePath, *codec, *tag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hprose-gen:", err)
+		os.Exit(1)
+	}
+	if err = ioutil.WriteFile(filename, src, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, "hprose-gen:", err)
+		os.Exit(1)
+	}
+}
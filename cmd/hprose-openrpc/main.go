@@ -0,0 +1,96 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * cmd/hprose-openrpc/main.go                             *
+ *                                                        *
+ * hprose OpenRPC document exporter.                      *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+// hprose-openrpc exports the OpenRPC document of a running hprose
+// HttpService whose OpenRPCPath is set.
+//
+// Usage:
+//
+//	hprose-openrpc [-output=openrpc.json] http://127.0.0.1:8080/openrpc.json
+//
+// The document is written to the standard output when -output is not set.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+var output = flag.String("output", "", "output file name; default is the standard output")
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage of hprose-openrpc:")
+	fmt.Fprintln(os.Stderr, "\thprose-openrpc [flags] url")
+	fmt.Fprintln(os.Stderr, "Flags:")
+	flag.PrintDefaults()
+}
+
+func fetch(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(url + ": " + resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var doc struct {
+		OpenRPC string `json:"openrpc"`
+	}
+	if err = json.Unmarshal(data, &doc); err != nil || doc.OpenRPC == "" {
+		return nil, errors.New(url + " is not an OpenRPC document")
+	}
+	buf := new(bytes.Buffer)
+	if err = json.Indent(buf, data, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	data, err := fetch(flag.Arg(0))
+	if err == nil {
+		if *output == "" {
+			_, err = os.Stdout.Write(data)
+		} else {
+			err = ioutil.WriteFile(*output, data, 0644)
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "hprose-openrpc:", err)
+		os.Exit(1)
+	}
+}
@@ -12,13 +12,18 @@
  *                                                        *
  * hprose context for Go.                                 *
  *                                                        *
- * LastModified: May 24, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
 
 package hprose
 
+import (
+	"context"
+	"time"
+)
+
 // Context is the hprose context
 type Context interface {
 	UserData() map[string]interface{}
@@ -40,9 +45,26 @@ type Context interface {
 	SetInterface(key string, value interface{})
 }
 
+// contextBinder is the hprose context which binds a context.Context,
+// such as BaseContext
+type contextBinder interface {
+	Context() context.Context
+	SetContext(ctx context.Context)
+}
+
+// getContext returns the context.Context bound to the hprose context,
+// it never returns nil
+func getContext(c Context) context.Context {
+	if binder, ok := c.(contextBinder); ok {
+		return binder.Context()
+	}
+	return backgroundContext
+}
+
 // BaseContext is the hprose base context
 type BaseContext struct {
 	userData map[string]interface{}
+	ctx      context.Context
 }
 
 // NewBaseContext is the constructor of BaseContext
@@ -57,6 +79,20 @@ func (context *BaseContext) UserData() map[string]interface{} {
 	return context.userData
 }
 
+// Context return the context.Context bound to the hprose context,
+// it never returns nil
+func (context *BaseContext) Context() context.Context {
+	if context.ctx == nil {
+		return backgroundContext
+	}
+	return context.ctx
+}
+
+// SetContext bind the context.Context to the hprose context
+func (context *BaseContext) SetContext(ctx context.Context) {
+	context.ctx = ctx
+}
+
 // GetInt from hprose context
 func (context *BaseContext) GetInt(key string) (value int, ok bool) {
 	if value, ok := context.userData[key]; ok {
@@ -174,3 +210,19 @@ func (context *BaseContext) SetString(key string, value string) {
 func (context *BaseContext) SetInterface(key string, value interface{}) {
 	context.userData[key] = value
 }
+
+var backgroundContext = context.Background()
+
+// timeoutHeader is the header name of the remaining milliseconds before
+// the deadline of the invocation
+const timeoutHeader = "timeout"
+
+func setContextTimeout(c Context, timeout time.Duration) context.CancelFunc {
+	binder, ok := c.(contextBinder)
+	if !ok {
+		return func() {}
+	}
+	ctx, cancel := context.WithTimeout(binder.Context(), timeout)
+	binder.SetContext(ctx)
+	return cancel
+}
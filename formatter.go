@@ -12,7 +12,7 @@
  *                                                        *
  * hprose Formatter for Go.                               *
  *                                                        *
- * LastModified: May 24, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -21,6 +21,7 @@ package hprose
 
 import (
 	"bytes"
+	"errors"
 	"io"
 	"unicode/utf8"
 )
@@ -61,6 +62,15 @@ func (r *BytesReader) ReadByte() (b byte, err error) {
 	return
 }
 
+// UnreadByte from BytesReader
+func (r *BytesReader) UnreadByte() error {
+	if r.Pos <= 0 {
+		return errors.New("BytesReader.UnreadByte: at beginning of slice")
+	}
+	r.Pos--
+	return nil
+}
+
 // ReadRune from BytesReader
 func (r *BytesReader) ReadRune() (ch rune, size int, err error) {
 	if r.Pos >= len(r.Bytes) {
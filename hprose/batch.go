@@ -0,0 +1,254 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/batch.go                                        *
+ *                                                        *
+ * hprose batch invoking for Go.                          *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+)
+
+// Batch queues several invocations and sends them in one hprose request.
+//
+// For example:
+//
+//	batch := client.Batch()
+//	var sum int
+//	var hello string
+//	err1 := batch.Invoke("sum", []interface{}{1, 2, 3}, nil, &sum)
+//	err2 := batch.Invoke("hello", []interface{}{"World"}, nil, &hello)
+//	if err := batch.End(); err == nil {
+//		fmt.Println(<-err1, sum)
+//		fmt.Println(<-err2, hello)
+//	}
+type Batch struct {
+	client *BaseClient
+	calls  []*batchCall
+}
+
+type batchCall struct {
+	name    string
+	args    []reflect.Value
+	options *InvokeOptions
+	result  []reflect.Value
+	err     error
+	done    chan error
+}
+
+// Batch begin a batch invoking on the client
+func (client *BaseClient) Batch() *Batch {
+	batch := new(Batch)
+	batch.client = client
+	batch.calls = make([]*batchCall, 0)
+	return batch
+}
+
+// Invoke queue the remote method invoking,
+// the returned chan receives the error of this invoking after the batch is ended
+func (batch *Batch) Invoke(name string, args []interface{}, options *InvokeOptions, result interface{}) <-chan error {
+	if result == nil {
+		panic("The argument result can't be nil")
+	}
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Ptr {
+		panic("The argument result must be pointer type")
+	}
+	if v.Elem().Kind() == reflect.Chan {
+		panic("The argument result can't be chan type in batch")
+	}
+	if options == nil {
+		options = new(InvokeOptions)
+	}
+	if options.ResultMode == Raw || options.ResultMode == RawWithEndTag {
+		panic("The ResultMode can't be Raw or RawWithEndTag in batch")
+	}
+	count := len(args)
+	a := make([]reflect.Value, count)
+	v = reflect.ValueOf(args)
+	for i := 0; i < count; i++ {
+		a[i] = v.Index(i).Elem()
+	}
+	byref := batch.client.ByRef
+	if br, ok := options.ByRef.(bool); ok {
+		byref = br
+	}
+	if byref && !checkRefArgs(a) {
+		panic("The elements in args must be pointer when options.ByRef is true.")
+	}
+	call := &batchCall{
+		name:    name,
+		args:    a,
+		options: options,
+		result:  []reflect.Value{reflect.ValueOf(result).Elem()},
+		done:    make(chan error, 1),
+	}
+	batch.calls = append(batch.calls, call)
+	return call.done
+}
+
+// End send the queued invocations in one request and wait for the results.
+// The returned error is the error of the whole request, it is also sent to
+// the error chan of every invoking.
+func (batch *Batch) End() error {
+	return batch.EndContext(backgroundContext)
+}
+
+// EndContext is the same as End, but the request is aborted when the ctx is done
+func (batch *Batch) EndContext(ctx context.Context) error {
+	if ctx == nil {
+		panic("The argument ctx can't be nil")
+	}
+	calls := batch.calls
+	batch.calls = make([]*batchCall, 0)
+	if len(calls) == 0 {
+		return nil
+	}
+	err := batch.client.batchInvoke(ctx, calls)
+	for _, call := range calls {
+		if err != nil {
+			call.done <- err
+		} else {
+			call.done <- call.err
+		}
+	}
+	return err
+}
+
+func (client *BaseClient) batchInvoke(ctx context.Context, calls []*batchCall) (err error) {
+	defer func() {
+		if e := recover(); e != nil && err == nil {
+			err = client.recoverError(e)
+		}
+	}()
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+	context := new(ClientContext)
+	context.BaseContext = NewBaseContext()
+	context.Client = client.Client
+	context.SetContext(ctx)
+	if client.codec != nil {
+		return client.batchCodecInvoke(ctx, calls, context)
+	}
+	buf := new(bytes.Buffer)
+	if err = client.writeHeaders(buf, context); err != nil {
+		return err
+	}
+	oneway := make([]bool, len(calls))
+	for i, call := range calls {
+		if err = client.writeCall(buf, call.name, call.args, call.options); err != nil {
+			return err
+		}
+		oneway[i] = call.options.Oneway
+	}
+	if err = buf.WriteByte(TagEnd); err != nil {
+		return err
+	}
+	context.SetInterface(onewayContextKey, oneway)
+	var data []byte
+	if data, err = client.exchange(buf.Bytes(), context); err != nil {
+		return err
+	}
+	return client.doBatchInput(data, calls, context)
+}
+
+func (client *BaseClient) batchCodecInvoke(ctx context.Context, calls []*batchCall, context *ClientContext) (err error) {
+	codecCalls := make([]*CodecCall, len(calls))
+	for i, call := range calls {
+		if codecCalls[i], err = client.newCodecCall(call.name, call.args, call.options, 1); err != nil {
+			return err
+		}
+		codecCalls[i].Batch = true
+	}
+	var data []byte
+	if data, err = client.encodeCodecRequest(codecCalls, context); err != nil {
+		return err
+	}
+	if data, err = client.exchange(data, context); err != nil {
+		return err
+	}
+	replies, err := client.decodeCodecResponse(data, context)
+	if err != nil {
+		return err
+	}
+	for i, call := range calls {
+		call.err = client.setCodecReply(replies[i], call.args, call.result)
+	}
+	return nil
+}
+
+func (client *BaseClient) doBatchInput(data []byte, calls []*batchCall, context *ClientContext) (err error) {
+	if len(data) == 0 || data[len(data)-1] != TagEnd {
+		return errors.New("Wrong Response: \r\n" + string(data))
+	}
+	istream := NewBytesReader(data)
+	reader := NewReader(istream, false)
+	n := len(calls)
+	i := 0
+	var lastErr error
+	var tag byte
+	for tag, err = istream.ReadByte(); err == nil && tag != TagEnd; tag, err = istream.ReadByte() {
+		switch tag {
+		case TagResult:
+			if i >= n {
+				return errors.New("Wrong Response: \r\n" + string(data))
+			}
+			call := calls[i]
+			i++
+			if err = readResult(reader, call.options.ResultMode, call.result); err != nil {
+				return err
+			}
+		case TagArgument:
+			if i == 0 {
+				return errors.New("Wrong Response: \r\n" + string(data))
+			}
+			if err = readArguments(reader, calls[i-1].args); err != nil {
+				return err
+			}
+		case TagError:
+			if i >= n {
+				return errors.New("Wrong Response: \r\n" + string(data))
+			}
+			var e *RemoteError
+			if e, err = readError(reader, istream); err != nil {
+				return err
+			}
+			lastErr = e
+			calls[i].err = e
+			i++
+		default:
+			return errors.New("Wrong Response: \r\n" + string(data))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if i < n {
+		// the whole request failed, the service returns only one error.
+		if lastErr == nil {
+			return errors.New("Wrong Response: \r\n" + string(data))
+		}
+		for ; i < n; i++ {
+			calls[i].err = lastErr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/circuit_breaker.go                              *
+ *                                                        *
+ * hprose circuit breaker for Go.                         *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit of CircuitBreaker
+type CircuitState int
+
+// The states of a circuit
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+var circuitStateNames = []string{"Closed", "Open", "HalfOpen"}
+
+func (state CircuitState) String() string {
+	if state >= 0 && int(state) < len(circuitStateNames) {
+		return circuitStateNames[state]
+	}
+	return "Unknown"
+}
+
+// CircuitOpenError is returned without sending the request when the
+// circuit is open
+type CircuitOpenError struct {
+	Key string // the key of the circuit
+}
+
+// Error return the error message
+func (e *CircuitOpenError) Error() string {
+	return "The circuit of " + e.Key + " is open."
+}
+
+type circuit struct {
+	state    CircuitState
+	start    time.Time
+	requests int
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// CircuitBreaker fails the requests fast when the endpoint is down.
+//
+// The requests of every endpoint, or of every method of every endpoint when
+// PerMethod is true, go through their own circuit. The closed circuit
+// counts the requests and the failures in every Window, it opens when there
+// are MinRequests requests and the rate of the failures reaches
+// FailureRate. The open circuit fails the requests by CircuitOpenError,
+// after OpenTime it half-opens and lets one request probe the endpoint, the
+// circuit closes when the probe succeeds and opens again when it fails.
+type CircuitBreaker struct {
+	PerMethod   bool             // the methods have their own circuits
+	FailureRate float64          // the rate of the failures which opens the circuit
+	MinRequests int              // the min requests in the window to open the circuit
+	Window      time.Duration    // the time window of the failure rate
+	OpenTime    time.Duration    // the time before the open circuit half-opens
+	Failure     func(error) bool // classifies the failures, nil is IsRetryable
+	mutex       sync.Mutex
+	circuits    map[string]*circuit
+}
+
+// NewCircuitBreaker is the constructor of CircuitBreaker
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		FailureRate: 0.5,
+		MinRequests: 10,
+		Window:      10 * time.Second,
+		OpenTime:    5 * time.Second,
+	}
+}
+
+// Key returns the key of the circuit of the method on the endpoint, the
+// method is ignored unless PerMethod is true
+func (breaker *CircuitBreaker) Key(uri string, method string) string {
+	if breaker.PerMethod && method != "" {
+		return uri + "#" + method
+	}
+	return uri
+}
+
+func (breaker *CircuitBreaker) getCircuit(key string) *circuit {
+	if breaker.circuits == nil {
+		breaker.circuits = make(map[string]*circuit)
+	}
+	c := breaker.circuits[key]
+	if c == nil {
+		c = &circuit{start: time.Now()}
+		breaker.circuits[key] = c
+	}
+	return c
+}
+
+// State returns the state of the circuit
+func (breaker *CircuitBreaker) State(key string) CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	if c := breaker.circuits[key]; c != nil {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// States returns the states of all the circuits
+func (breaker *CircuitBreaker) States() map[string]CircuitState {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	states := make(map[string]CircuitState, len(breaker.circuits))
+	for key, c := range breaker.circuits {
+		states[key] = c.state
+	}
+	return states
+}
+
+// Allow returns nil when the request can be sent through the circuit, it
+// half-opens the open circuit after OpenTime and then the request is the
+// probe, so every allowed request must be reported by Report.
+func (breaker *CircuitBreaker) Allow(key string) error {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	c := breaker.getCircuit(key)
+	now := time.Now()
+	switch c.state {
+	case CircuitClosed:
+		if now.Sub(c.start) >= breaker.Window {
+			c.start, c.requests, c.failures = now, 0, 0
+		}
+		return nil
+	case CircuitOpen:
+		if now.Sub(c.openedAt) < breaker.OpenTime {
+			return &CircuitOpenError{key}
+		}
+		c.state = CircuitHalfOpen
+	}
+	if c.probing {
+		return &CircuitOpenError{key}
+	}
+	c.probing = true
+	return nil
+}
+
+// Report records the result of the request allowed by Allow, the canceled
+// requests aren't counted
+func (breaker *CircuitBreaker) Report(key string, err error) {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	c := breaker.getCircuit(key)
+	canceled := errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+	failed := err != nil && !canceled && breaker.failure(err)
+	switch c.state {
+	case CircuitClosed:
+		if canceled {
+			return
+		}
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= breaker.MinRequests && float64(c.failures) >= breaker.FailureRate*float64(c.requests) {
+			c.state, c.openedAt = CircuitOpen, time.Now()
+		}
+	case CircuitHalfOpen:
+		c.probing = false
+		switch {
+		case failed:
+			c.state, c.openedAt = CircuitOpen, time.Now()
+		case !canceled:
+			c.state = CircuitClosed
+			c.start, c.requests, c.failures = time.Now(), 0, 0
+		}
+	}
+}
+
+// available returns whether the circuit allows a request now, it doesn't
+// change the circuit
+func (breaker *CircuitBreaker) available(key string) bool {
+	breaker.mutex.Lock()
+	defer breaker.mutex.Unlock()
+	c := breaker.circuits[key]
+	switch {
+	case c == nil || c.state == CircuitClosed:
+		return true
+	case c.state == CircuitOpen:
+		return time.Since(c.openedAt) >= breaker.OpenTime
+	}
+	return !c.probing
+}
+
+func (breaker *CircuitBreaker) failure(err error) bool {
+	if breaker.Failure != nil {
+		return breaker.Failure(err)
+	}
+	return IsRetryable(err)
+}
+
+// call sends the request through the circuit of the method on the endpoint
+func (breaker *CircuitBreaker) call(uri string, context *ClientContext, send func() ([]byte, error)) ([]byte, error) {
+	if breaker == nil {
+		return send()
+	}
+	method, _ := context.GetString(methodContextKey)
+	key := breaker.Key(uri, method)
+	if err := breaker.Allow(key); err != nil {
+		return nil, err
+	}
+	response, err := send()
+	breaker.Report(key, err)
+	return response, err
+}
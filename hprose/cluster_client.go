@@ -0,0 +1,323 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/cluster_client.go                               *
+ *                                                        *
+ * hprose cluster client for Go.                          *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"hash/crc32"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Balance is the load balance strategy of ClusterClient
+type Balance int
+
+// The load balance strategies of ClusterClient
+const (
+	BalanceRoundRobin Balance = iota
+	BalanceRandom
+	BalanceLeastPending
+	BalanceConsistentHash
+)
+
+// clusterReplicas is the number of the virtual nodes of an endpoint on the
+// consistent hash ring
+const clusterReplicas = 160
+
+type hashKeyContextKey struct{}
+
+// WithHashKey returns the ctx with the key of BalanceConsistentHash, the
+// invokings with the same key are sent to the same endpoint while it is
+// available. The invokings without the key are balanced by round-robin.
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyContextKey{}, key)
+}
+
+type clusterEndpoint struct {
+	client   Client
+	pending  int32
+	failures int
+	ejected  bool
+	probeAt  time.Time
+}
+
+type clusterNode struct {
+	hash     uint32
+	endpoint *clusterEndpoint
+}
+
+// ClusterClient is the hprose client of the replicated services, it sends
+// every request to one of the endpoints chosen by the load balance
+// strategy. The endpoints can mix all the registered schemes, they share
+// the filters, the handlers and the codec of ClusterClient.
+//
+// The endpoint which fails MaxFailures times in a row by the retryable
+// errors (see IsRetryable) is ejected, after EjectTime one request probes
+// it again, the endpoint is restored when the request succeeds. The failed
+// idempotent invokings are sent to the other endpoints by the RetryPolicy.
+type ClusterClient struct {
+	*BaseClient
+	MaxFailures int           // the failures in a row which eject the endpoint
+	EjectTime   time.Duration // the time before the ejected endpoint is probed
+	balance     Balance
+	mutex       sync.Mutex
+	endpoints   []*clusterEndpoint
+	ring        []clusterNode
+	next        uint32
+}
+
+type clusterTransporter struct {
+	*ClusterClient
+}
+
+// NewClusterClient is the constructor of ClusterClient
+func NewClusterClient(uris []string, balance Balance) (client *ClusterClient) {
+	trans := new(clusterTransporter)
+	client = new(ClusterClient)
+	client.BaseClient = NewBaseClient(trans)
+	client.Client = client
+	client.MaxFailures = 3
+	client.EjectTime = 10 * time.Second
+	client.balance = balance
+	trans.ClusterClient = client
+	client.SetUris(uris)
+	return client
+}
+
+// Uri return the uris of the endpoints separated by commas
+func (client *ClusterClient) Uri() string {
+	return strings.Join(client.Uris(), ",")
+}
+
+// SetUri set the only endpoint of the client
+func (client *ClusterClient) SetUri(uri string) {
+	client.SetUris([]string{uri})
+}
+
+// Uris return the uris of the endpoints
+func (client *ClusterClient) Uris() []string {
+	endpoints := client.getEndpoints()
+	uris := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		uris[i] = endpoint.client.Uri()
+	}
+	return uris
+}
+
+// SetUris set the endpoints of the client, the clients of the endpoints are
+// created by NewClient
+func (client *ClusterClient) SetUris(uris []string) {
+	if len(uris) == 0 {
+		panic("The uris can't be empty.")
+	}
+	endpoints := make([]*clusterEndpoint, len(uris))
+	ring := make([]clusterNode, 0, len(uris)*clusterReplicas)
+	for i, uri := range uris {
+		endpoint := &clusterEndpoint{client: NewClient(uri)}
+		if codec := client.Codec(); codec != nil {
+			endpoint.client.SetCodec(codec)
+		}
+		endpoints[i] = endpoint
+		for j := 0; j < clusterReplicas; j++ {
+			hash := crc32.ChecksumIEEE([]byte(uri + "#" + strconv.Itoa(j)))
+			ring = append(ring, clusterNode{hash, endpoint})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool { return ring[i].hash < ring[j].hash })
+	client.mutex.Lock()
+	old := client.endpoints
+	client.endpoints = endpoints
+	client.ring = ring
+	client.mutex.Unlock()
+	for _, endpoint := range old {
+		endpoint.client.Close()
+	}
+}
+
+// Clients return the clients of the endpoints, which can be used to
+// configure the endpoints
+func (client *ClusterClient) Clients() []Client {
+	endpoints := client.getEndpoints()
+	clients := make([]Client, len(endpoints))
+	for i, endpoint := range endpoints {
+		clients[i] = endpoint.client
+	}
+	return clients
+}
+
+func (client *ClusterClient) getEndpoints() []*clusterEndpoint {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	return client.endpoints
+}
+
+// SetCodec set the codec of the client and the endpoints, it panics when
+// the codec isn't supported by the clients of the endpoints
+func (client *ClusterClient) SetCodec(codec Codec) {
+	for _, endpoint := range client.getEndpoints() {
+		endpoint.client.SetCodec(codec)
+	}
+	client.codec = codec
+}
+
+// TLSClientConfig return the tls.Config of the first endpoint
+func (client *ClusterClient) TLSClientConfig() *tls.Config {
+	return client.getEndpoints()[0].client.TLSClientConfig()
+}
+
+// SetTLSClientConfig set the tls.Config of the endpoints
+func (client *ClusterClient) SetTLSClientConfig(config *tls.Config) {
+	for _, endpoint := range client.getEndpoints() {
+		endpoint.client.SetTLSClientConfig(config)
+	}
+}
+
+// SetKeepAlive set the keepalive of the endpoints
+func (client *ClusterClient) SetKeepAlive(enable bool) {
+	for _, endpoint := range client.getEndpoints() {
+		endpoint.client.SetKeepAlive(enable)
+	}
+}
+
+// Close the endpoints
+func (client *ClusterClient) Close() {
+	for _, endpoint := range client.getEndpoints() {
+		endpoint.client.Close()
+	}
+}
+
+// choose returns the endpoint of the request, the ejected endpoints and
+// the endpoints which aren't allowed are skipped, all the endpoints are used
+// when all of them are skipped
+func (client *ClusterClient) choose(ctx context.Context, allowed func(endpoint *clusterEndpoint) bool) *clusterEndpoint {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	now := time.Now()
+	available := func(endpoint *clusterEndpoint) bool {
+		return (!endpoint.ejected || !now.Before(endpoint.probeAt)) &&
+			(allowed == nil || allowed(endpoint))
+	}
+	candidates := make([]*clusterEndpoint, 0, len(client.endpoints))
+	for _, endpoint := range client.endpoints {
+		if available(endpoint) {
+			candidates = append(candidates, endpoint)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = client.endpoints
+		available = func(*clusterEndpoint) bool { return true }
+	}
+	var endpoint *clusterEndpoint
+	key, hashed := ctx.Value(hashKeyContextKey{}).(string)
+	switch {
+	case client.balance == BalanceConsistentHash && hashed:
+		hash := crc32.ChecksumIEEE([]byte(key))
+		n := len(client.ring)
+		i := sort.Search(n, func(i int) bool { return client.ring[i].hash >= hash })
+		for j := 0; j < n && endpoint == nil; j++ {
+			if node := client.ring[(i+j)%n]; available(node.endpoint) {
+				endpoint = node.endpoint
+			}
+		}
+	case client.balance == BalanceRandom:
+		endpoint = candidates[rand.Intn(len(candidates))]
+	case client.balance == BalanceLeastPending:
+		start := int(client.next % uint32(len(candidates)))
+		client.next++
+		for i := range candidates {
+			e := candidates[(start+i)%len(candidates)]
+			if endpoint == nil || atomic.LoadInt32(&e.pending) < atomic.LoadInt32(&endpoint.pending) {
+				endpoint = e
+			}
+		}
+	default:
+		endpoint = candidates[client.next%uint32(len(candidates))]
+		client.next++
+	}
+	if endpoint.ejected {
+		endpoint.probeAt = now.Add(client.EjectTime)
+	}
+	return endpoint
+}
+
+// report updates the state of the endpoint by the result of the request
+func (client *ClusterClient) report(endpoint *clusterEndpoint, err error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	if err == nil || !IsRetryable(err) {
+		endpoint.failures = 0
+		endpoint.ejected = false
+		return
+	}
+	endpoint.failures++
+	if endpoint.ejected || endpoint.failures >= client.MaxFailures {
+		endpoint.ejected = true
+		endpoint.probeAt = time.Now().Add(client.EjectTime)
+	}
+}
+
+func (trans *clusterTransporter) SendAndReceive(uri string, data []byte) ([]byte, error) {
+	return trans.SendAndReceiveContext(backgroundContext, uri, data)
+}
+
+func (trans *clusterTransporter) SendAndReceiveContext(ctx context.Context, uri string, data []byte) ([]byte, error) {
+	return trans.send(ctx, trans.choose(ctx, nil), data)
+}
+
+// dispatch sends the request through the circuit of the endpoint, the
+// endpoints with the open circuits are skipped
+func (trans *clusterTransporter) dispatch(request []byte, context *ClientContext) ([]byte, error) {
+	ctx := context.Context()
+	breaker := trans.CircuitBreaker
+	var allowed func(endpoint *clusterEndpoint) bool
+	if breaker != nil {
+		method, _ := context.GetString(methodContextKey)
+		allowed = func(endpoint *clusterEndpoint) bool {
+			return breaker.available(breaker.Key(endpoint.client.Uri(), method))
+		}
+	}
+	endpoint := trans.choose(ctx, allowed)
+	return breaker.call(endpoint.client.Uri(), context, func() ([]byte, error) {
+		return trans.send(ctx, endpoint, request)
+	})
+}
+
+func (trans *clusterTransporter) send(ctx context.Context, endpoint *clusterEndpoint, data []byte) ([]byte, error) {
+	client, ok := endpoint.client.(interface {
+		sendAndReceive(ctx context.Context, data []byte) ([]byte, error)
+	})
+	if !ok {
+		return nil, errors.New("The client of " + endpoint.client.Uri() + " can't be used by ClusterClient.")
+	}
+	atomic.AddInt32(&endpoint.pending, 1)
+	data, err := client.sendAndReceive(ctx, data)
+	atomic.AddInt32(&endpoint.pending, -1)
+	if ctx.Err() == nil {
+		trans.report(endpoint, err)
+	}
+	return data, err
+}
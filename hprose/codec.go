@@ -0,0 +1,338 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/codec.go                                        *
+ *                                                        *
+ * hprose codec for Go.                                   *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"errors"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+// Codec is the wire format of the requests and the responses.
+//
+// The hprose format is built in and used when there is no codec, the other
+// formats (such as JSONCodec) are plugged into the service by AddCodec and
+// into the client by SetCodec. The values are encoded and decoded by the
+// codec directly, the envelope of the calls, results and errors is encoded
+// by the codec too.
+type Codec interface {
+	// ContentType is the MIME type of the encoded requests and responses
+	ContentType() string
+	// Marshal encodes the value v
+	Marshal(v reflect.Value) ([]byte, error)
+	// Unmarshal decodes the data into the settable value v
+	Unmarshal(data []byte, v reflect.Value) error
+	// EncodeRequest encodes the calls, it may set the ID of the calls
+	EncodeRequest(calls []*CodecCall) ([]byte, error)
+	// DecodeRequest decodes the calls
+	DecodeRequest(data []byte) ([]*CodecCall, error)
+	// EncodeResponse encodes the replies of the calls
+	EncodeResponse(calls []*CodecCall, replies []*CodecReply) ([]byte, error)
+	// DecodeResponse decodes the replies in the order of the calls
+	DecodeResponse(data []byte, calls []*CodecCall) ([]*CodecReply, error)
+}
+
+// CodecCall is a call in the request, the arguments are encoded by Marshal.
+type CodecCall struct {
+	ID     interface{}       // the call id of the codec
+	Name   string            // the method name
+	Args   [][]byte          // the arguments by position
+	Named  map[string][]byte // the arguments by name, Args has the whole object
+	ByRef  bool              // the arguments are sent back
+	Oneway bool              // the call expects no reply
+	Batch  bool              // the call is in a batch, even if it is the only one
+	Error  *RemoteError      // the call is invalid, the service replies the error
+	// Results is the number of the result values the client expects,
+	// the results are encoded as a list when it isn't 1.
+	Results int
+}
+
+// CodecReply is the reply of a call, the values are encoded by Marshal.
+//
+// The service encodes Results as one value when there is only one result,
+// or as a list, and the client splits the list by the Results of the call.
+type CodecReply struct {
+	Results [][]byte
+	Args    [][]byte
+	Error   *RemoteError
+}
+
+// codecContextKey is the key of the codec of the request in the context
+const codecContextKey = "hprose.codec"
+
+// codecCallsContextKey is the key of the calls of the request in the
+// client context
+const codecCallsContextKey = "hprose.codec.calls"
+
+func getCodec(context Context) Codec {
+	if c, ok := context.GetInterface(codecContextKey); ok {
+		codec, _ := c.(Codec)
+		return codec
+	}
+	return nil
+}
+
+// AddCodec adds a codec to the service, the requests of its content type
+// and the alias content types are handled by the codec
+func (service *BaseService) AddCodec(codec Codec, aliases ...string) {
+	if service.codecs == nil {
+		service.codecs = make(map[string]Codec)
+	}
+	service.codecs[codec.ContentType()] = codec
+	for _, alias := range aliases {
+		service.codecs[strings.ToLower(alias)] = codec
+	}
+}
+
+// GetCodec returns the codec of the content type, or nil for the hprose
+// format
+func (service *BaseService) GetCodec(contentType string) Codec {
+	if service.codecs == nil {
+		return nil
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	return service.codecs[strings.ToLower(contentType)]
+}
+
+func (service *BaseService) handleCodec(codec Codec, data []byte, context Context) []byte {
+	calls, err := codec.DecodeRequest(data)
+	if err != nil {
+		return service.sendCodecError(codec, err, context)
+	}
+	replies := make([]*CodecReply, len(calls))
+	for i, call := range calls {
+		if call.Error != nil {
+			replies[i] = &CodecReply{Error: newRemoteError(service.fireErrorEvent(call.Error, context))}
+		} else {
+			replies[i] = service.invokeCodec(codec, call, context)
+		}
+	}
+	return service.codecResponseEnd(codec, calls, replies, context)
+}
+
+func (service *BaseService) sendCodecError(codec Codec, err error, context Context) []byte {
+	reply := &CodecReply{Error: newRemoteError(service.fireErrorEvent(err, context))}
+	return service.codecResponseEnd(codec, []*CodecCall{{}}, []*CodecReply{reply}, context)
+}
+
+func (service *BaseService) codecResponseEnd(codec Codec, calls []*CodecCall, replies []*CodecReply, context Context) []byte {
+	data, err := codec.EncodeResponse(calls, replies)
+	if err != nil {
+		reply := &CodecReply{Error: newRemoteError(service.fireErrorEvent(err, context))}
+		data, _ = codec.EncodeResponse([]*CodecCall{{}}, []*CodecReply{reply})
+	}
+	return data
+}
+
+func (service *BaseService) invokeCodec(codec Codec, call *CodecCall, context Context) (reply *CodecReply) {
+	reply = new(CodecReply)
+	defer func() {
+		if e := recover(); e != nil {
+			reply = &CodecReply{Error: newRemoteError(service.fireErrorEvent(service.recoverError(e), context))}
+		}
+	}()
+	remoteMethod := service.getMethod(call.Name)
+	if remoteMethod == nil && service.missingMethod() == nil {
+		err := &codecError{JSONRPCMethodNotFound, errors.New("Can't find this method " + call.Name)}
+		return &CodecReply{Error: newRemoteError(service.fireErrorEvent(err, context))}
+	}
+	args, err := service.decodeArgs(codec, remoteMethod, call, context)
+	if err != nil {
+		err = &codecError{JSONRPCInvalidParams, err}
+	}
+	var result []reflect.Value
+	if err == nil {
+		remoteMethod, result, err = service.call(call.Name, remoteMethod, args, call.ByRef, context)
+	}
+	if err == nil && remoteMethod.ResultMode != Normal {
+		err = errors.New("the result mode " + remoteMethod.ResultMode.String() + " isn't supported by the codec")
+	}
+	if err == nil {
+		reply.Results, err = marshalValues(codec, result)
+	}
+	if err == nil && call.ByRef {
+		reply.Args, err = marshalValues(codec, args)
+	}
+	if err != nil {
+		reply = &CodecReply{Error: newRemoteError(service.fireErrorEvent(err, context))}
+	}
+	return reply
+}
+
+// codecError is the error of the codec path with the JSON-RPC error code,
+// such as the unknown method and the arguments which can't be decoded.
+type codecError struct {
+	code int
+	err  error
+}
+
+func (e *codecError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codecError) Code() int {
+	return e.code
+}
+
+func (e *codecError) Unwrap() error {
+	return e.err
+}
+
+// decodeArgs decodes the arguments for the parameters of the method like
+// readArgs, the named arguments are ordered by the ParamNames of the method.
+func (service *BaseService) decodeArgs(codec Codec, remoteMethod *Method, call *CodecCall, context Context) (args []reflect.Value, err error) {
+	raw := call.Args
+	if call.Named != nil && remoteMethod != nil && remoteMethod.ParamNames != nil {
+		names := remoteMethod.ParamNames
+		raw = make([][]byte, len(names))
+	NEXT:
+		for name, value := range call.Named {
+			for i := range names {
+				if strings.EqualFold(names[i], name) {
+					raw[i] = value
+					continue NEXT
+				}
+			}
+			return nil, errors.New("unknown param " + name)
+		}
+	}
+	count := len(raw)
+	var ft reflect.Type
+	offset, n := 0, count
+	if remoteMethod != nil {
+		ft = remoteMethod.Function.Type()
+		if hasContextParam(ft) {
+			offset = 1
+		}
+		n = ft.NumIn() - offset
+		if ft.IsVariadic() {
+			n--
+		}
+	}
+	args = make([]reflect.Value, 0, count)
+	for i := 0; i < count; i++ {
+		var arg reflect.Value
+		switch {
+		case ft == nil:
+			var e interface{}
+			arg = reflect.ValueOf(&e).Elem()
+		case i < n:
+			arg = reflect.New(ft.In(i + offset)).Elem()
+		case ft.IsVariadic():
+			arg = reflect.New(ft.In(n + offset).Elem()).Elem()
+		default:
+			continue
+		}
+		if raw[i] != nil {
+			if err = codec.Unmarshal(raw[i], arg); err != nil {
+				return nil, err
+			}
+		}
+		args = append(args, arg)
+	}
+	if ft != nil && count+1 == n {
+		args = service.argsfixer.FixArgs(args, ft.In(count+offset), context)
+	}
+	return args, nil
+}
+
+func marshalValues(codec Codec, values []reflect.Value) (data [][]byte, err error) {
+	data = make([][]byte, len(values))
+	for i := range values {
+		if data[i], err = codec.Marshal(values[i]); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// Codec returns the codec of the client, or nil for the hprose format
+func (client *BaseClient) Codec() Codec {
+	return client.codec
+}
+
+// SetCodec sets the codec of the client, nil for the hprose format.
+//
+// Only the HttpClient sends the content type of the codec, so the other
+// clients panic when the codec isn't nil.
+func (client *BaseClient) SetCodec(codec Codec) {
+	if codec != nil {
+		panic("The codec isn't supported by this client.")
+	}
+	client.codec = nil
+}
+
+func (client *BaseClient) newCodecCall(name string, args []reflect.Value, options *InvokeOptions, results int) (call *CodecCall, err error) {
+	if options.ResultMode != Normal {
+		return nil, errors.New("the result mode " + options.ResultMode.String() + " isn't supported by the codec")
+	}
+	byref := client.ByRef
+	if br, ok := options.ByRef.(bool); ok {
+		byref = br
+	}
+	call = &CodecCall{Name: name, ByRef: byref, Oneway: options.Oneway, Results: results}
+	if call.Args, err = marshalValues(client.codec, args); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+func (client *BaseClient) encodeCodecRequest(calls []*CodecCall, context *ClientContext) ([]byte, error) {
+	data, err := client.codec.EncodeRequest(calls)
+	if err != nil {
+		return nil, err
+	}
+	context.SetInterface(codecCallsContextKey, calls)
+	return data, nil
+}
+
+func (client *BaseClient) decodeCodecResponse(data []byte, context *ClientContext) ([]*CodecReply, error) {
+	c, _ := context.GetInterface(codecCallsContextKey)
+	calls := c.([]*CodecCall)
+	replies, err := client.codec.DecodeResponse(data, calls)
+	if err != nil {
+		return nil, err
+	}
+	if len(replies) != len(calls) {
+		return nil, errors.New("Wrong Response: \r\n" + string(data))
+	}
+	return replies, nil
+}
+
+// setCodecReply sets the results and the arguments of the call by the reply
+func (client *BaseClient) setCodecReply(reply *CodecReply, args []reflect.Value, result []reflect.Value) (err error) {
+	if reply.Error != nil {
+		return reply.Error
+	}
+	for i := 0; i < len(reply.Results) && i < len(result); i++ {
+		if err = client.codec.Unmarshal(reply.Results[i], result[i]); err != nil {
+			return err
+		}
+	}
+	for i := 0; i < len(reply.Args) && i < len(args); i++ {
+		if err = client.codec.Unmarshal(reply.Args[i], args[i].Elem()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
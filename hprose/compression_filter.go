@@ -0,0 +1,201 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/compression_filter.go                           *
+ *                                                        *
+ * hprose compression filter for Go.                      *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// The compression algorithms of CompressionFilter, the other algorithms
+// (such as zstd) can be registered by RegisterCompressor.
+const (
+	CompressionGzip    byte = 1
+	CompressionDeflate byte = 2
+)
+
+// compressionMagic starts the header of CompressionFilter, it is never the
+// first byte of the hprose, JSON or MessagePack data.
+const compressionMagic = 0xc1
+
+const (
+	compressionStored     = 0
+	compressionCompressed = 1
+)
+
+// compressionContextKey is the key of the algorithm of the request in the
+// service context
+const compressionContextKey = "hprose.compression"
+
+// The errors of CompressionFilter, InputFilter panics with them
+var (
+	ErrCompressionAlgorithm = errors.New("The compression algorithm isn't registered.")
+	ErrCompressionData      = errors.New("The compressed data is corrupted.")
+	ErrCompressionTooLarge  = errors.New("The decompressed data exceeds the MaxSize.")
+)
+
+// Compressor is a compression algorithm of CompressionFilter
+type Compressor interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type deflateCompressor struct{}
+
+func (deflateCompressor) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return flate.NewWriter(w, flate.DefaultCompression)
+}
+
+func (deflateCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+var compressors = struct {
+	sync.RWMutex
+	m map[byte]Compressor
+}{m: map[byte]Compressor{
+	CompressionGzip:    gzipCompressor{},
+	CompressionDeflate: deflateCompressor{},
+}}
+
+// RegisterCompressor registers the compressor of the algorithm,
+// the algorithm must be registered in the client and the service.
+func RegisterCompressor(algorithm byte, compressor Compressor) {
+	compressors.Lock()
+	compressors.m[algorithm] = compressor
+	compressors.Unlock()
+}
+
+func getCompressor(algorithm byte) Compressor {
+	compressors.RLock()
+	defer compressors.RUnlock()
+	return compressors.m[algorithm]
+}
+
+// CompressionFilter compresses the requests and the responses.
+//
+// The compressed data starts with a 3 bytes header: 0xc1, the algorithm and
+// whether the data is compressed. The client marks every request, the data
+// shorter than Threshold is stored without compression. The service
+// decompresses the marked requests and compresses the responses by the
+// algorithm of the request, the requests without the header are handled as
+// usual and get the uncompressed responses. So the same filter is used by
+// the client and the service, and the service accepts the clients without
+// the filter.
+type CompressionFilter struct {
+	Algorithm byte // the algorithm of the client
+	Threshold int  // the data shorter than Threshold isn't compressed
+	MaxSize   int  // the max size of the decompressed data, 0 is unlimited
+}
+
+// NewCompressionFilter is the constructor of CompressionFilter, the MaxSize
+// is 32MB by default, set it to a larger size (or 0 for unlimited) to accept
+// larger data.
+func NewCompressionFilter(algorithm byte) *CompressionFilter {
+	if getCompressor(algorithm) == nil {
+		panic("The compression algorithm isn't registered.")
+	}
+	return &CompressionFilter{Algorithm: algorithm, Threshold: 1024, MaxSize: 32 << 20}
+}
+
+// InputFilter decompresses the data which has the header, it panics with
+// ErrCompressionAlgorithm, ErrCompressionData or ErrCompressionTooLarge when
+// the data can't be decompressed
+func (filter *CompressionFilter) InputFilter(data []byte, context Context) []byte {
+	if len(data) < 3 || data[0] != compressionMagic {
+		return data
+	}
+	algorithm := data[1]
+	if _, ok := context.(*ClientContext); !ok {
+		context.SetInt(compressionContextKey, int(algorithm))
+	}
+	if data[2] == compressionStored {
+		return data[3:]
+	}
+	compressor := getCompressor(algorithm)
+	if compressor == nil {
+		panic(ErrCompressionAlgorithm)
+	}
+	reader, err := compressor.NewReader(bytes.NewReader(data[3:]))
+	if err != nil {
+		panic(fmt.Errorf("%w %v", ErrCompressionData, err))
+	}
+	defer reader.Close()
+	var r io.Reader = reader
+	if filter.MaxSize > 0 {
+		r = io.LimitReader(reader, int64(filter.MaxSize)+1)
+	}
+	result, err := ioutil.ReadAll(r)
+	if err != nil {
+		panic(fmt.Errorf("%w %v", ErrCompressionData, err))
+	}
+	if filter.MaxSize > 0 && len(result) > filter.MaxSize {
+		panic(ErrCompressionTooLarge)
+	}
+	return result
+}
+
+// OutputFilter compresses the data, the service compresses only the
+// responses of the marked requests
+func (filter *CompressionFilter) OutputFilter(data []byte, context Context) []byte {
+	algorithm := filter.Algorithm
+	if _, ok := context.(*ClientContext); !ok {
+		a, ok := context.GetInt(compressionContextKey)
+		if !ok {
+			return data
+		}
+		algorithm = byte(a)
+	}
+	header := []byte{compressionMagic, algorithm, compressionStored}
+	compressor := getCompressor(algorithm)
+	if len(data) < filter.Threshold || compressor == nil {
+		return append(header, data...)
+	}
+	buf := bytes.NewBuffer(make([]byte, 0, len(data)/2))
+	header[2] = compressionCompressed
+	buf.Write(header)
+	writer, err := compressor.NewWriter(buf)
+	if err == nil {
+		if _, err = writer.Write(data); err == nil {
+			err = writer.Close()
+		}
+	}
+	if err != nil || buf.Len() >= len(data)+len(header) {
+		header[2] = compressionStored
+		return append(header, data...)
+	}
+	return buf.Bytes()
+}
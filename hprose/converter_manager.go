@@ -0,0 +1,83 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/converter_manager.go                            *
+ *                                                        *
+ * hprose ConverterManager for Go.                        *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// EncodeFunc serializes the value of a registered type
+type EncodeFunc func(writer *Writer, v interface{}) error
+
+// DecodeFunc unserializes the value of a registered type,
+// it returns ErrNil when the serialized value is null.
+type DecodeFunc func(reader *Reader) (interface{}, error)
+
+type typeConverter struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+type converterManager struct {
+	converterCache map[reflect.Type]*typeConverter
+	size           int32
+	mutex          sync.RWMutex
+}
+
+// Register the encode and decode functions of the class.
+//
+// The Writer and Reader use them before the native serialization, so they
+// can also change the serialization of the types which hprose supports.
+func (cm *converterManager) Register(class reflect.Type, encode EncodeFunc, decode DecodeFunc) {
+	cm.mutex.Lock()
+	cm.converterCache[class] = &typeConverter{encode, decode}
+	atomic.StoreInt32(&cm.size, int32(len(cm.converterCache)))
+	cm.mutex.Unlock()
+}
+
+// Unregister the encode and decode functions of the class.
+func (cm *converterManager) Unregister(class reflect.Type) {
+	cm.mutex.Lock()
+	delete(cm.converterCache, class)
+	atomic.StoreInt32(&cm.size, int32(len(cm.converterCache)))
+	cm.mutex.Unlock()
+}
+
+func (cm *converterManager) getConverter(class reflect.Type) (converter *typeConverter) {
+	if atomic.LoadInt32(&cm.size) == 0 {
+		return nil
+	}
+	cm.mutex.RLock()
+	converter = cm.converterCache[class]
+	cm.mutex.RUnlock()
+	return converter
+}
+
+func initConverterManager() *converterManager {
+	cm := new(converterManager)
+	cm.converterCache = make(map[reflect.Type]*typeConverter)
+	return cm
+}
+
+// ConverterManager used to be register the encode and decode functions
+// of the types which you don't own for hprose serialize/unserialize.
+var ConverterManager = initConverterManager()
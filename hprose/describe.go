@@ -0,0 +1,198 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/describe.go                                     *
+ *                                                        *
+ * hprose service description for Go.                     *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"container/list"
+	"math/big"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DescribeMethodName is the name of the remote method which returns the
+// ServiceDescription when DescribeEnabled of the service is true
+const DescribeMethodName = "#describe"
+
+// MethodDescription describes a published method
+type MethodDescription struct {
+	Name       string   `json:"name"`
+	Params     []string `json:"params"`
+	Results    []string `json:"results"`
+	Variadic   bool     `json:"variadic"`
+	ResultMode string   `json:"resultMode"`
+	SimpleMode bool     `json:"simpleMode"`
+}
+
+// FieldDescription describes a field of the class
+type FieldDescription struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// ClassDescription describes a struct type used by the methods
+type ClassDescription struct {
+	Name   string             `json:"name"`
+	Fields []FieldDescription `json:"fields"`
+}
+
+// ServiceDescription describes the published methods and the classes
+type ServiceDescription struct {
+	Methods []MethodDescription `json:"methods"`
+	Classes []ClassDescription  `json:"classes"`
+}
+
+var builtinStructTypes = map[reflect.Type]bool{
+	reflect.TypeOf(time.Time{}): true,
+	reflect.TypeOf(big.Int{}):   true,
+	reflect.TypeOf(big.Rat{}):   true,
+	reflect.TypeOf(big.Float{}): true,
+	reflect.TypeOf(list.List{}): true,
+}
+
+type describer struct {
+	classes map[reflect.Type]*ClassDescription
+}
+
+// Describe returns the description of the published methods, the missing
+// method is not included.
+func (methods *Methods) Describe() *ServiceDescription {
+	d := &describer{make(map[reflect.Type]*ClassDescription)}
+	desc := &ServiceDescription{
+		Methods: make([]MethodDescription, 0, len(methods.MethodNames)),
+		Classes: make([]ClassDescription, 0),
+	}
+	for _, name := range methods.MethodNames {
+		if name == "*" {
+			continue
+		}
+		if method := methods.RemoteMethods[strings.ToLower(name)]; method != nil {
+			desc.Methods = append(desc.Methods, d.describeMethod(name, method))
+		}
+	}
+	for _, class := range d.classes {
+		desc.Classes = append(desc.Classes, *class)
+	}
+	sort.Slice(desc.Classes, func(i, j int) bool {
+		return desc.Classes[i].Name < desc.Classes[j].Name
+	})
+	return desc
+}
+
+func (d *describer) describeMethod(name string, method *Method) MethodDescription {
+	ft := method.Function.Type()
+	in := make([]reflect.Type, 0, ft.NumIn())
+	for i := 0; i < ft.NumIn(); i++ {
+		in = append(in, ft.In(i))
+	}
+	if hasContextParam(ft) {
+		in = in[1:]
+	}
+	if n := len(in); n > 0 && !ft.IsVariadic() && isContextParam(in[n-1]) {
+		in = in[:n-1]
+	}
+	out := make([]reflect.Type, 0, ft.NumOut())
+	for i := 0; i < ft.NumOut(); i++ {
+		out = append(out, ft.Out(i))
+	}
+	if n := len(out); n > 0 && out[n-1].Implements(errorType) {
+		out = out[:n-1]
+	}
+	m := MethodDescription{
+		Name:       name,
+		Params:     make([]string, len(in)),
+		Results:    make([]string, len(out)),
+		Variadic:   ft.IsVariadic(),
+		ResultMode: method.ResultMode.String(),
+		SimpleMode: method.SimpleMode,
+	}
+	for i, t := range in {
+		m.Params[i] = d.typeName(t)
+	}
+	for i, t := range out {
+		m.Results[i] = d.typeName(t)
+	}
+	return m
+}
+
+// contextParamTypes are the types of the last parameter which are filled
+// by the ArgsFixer of the services
+var contextParamTypes = map[reflect.Type]bool{
+	reflect.TypeOf((*Context)(nil)).Elem():   true,
+	reflect.TypeOf((*HttpContext)(nil)):      true,
+	reflect.TypeOf((*http.Request)(nil)):     true,
+	reflect.TypeOf((*StreamContext)(nil)):    true,
+	reflect.TypeOf((*TcpContext)(nil)):       true,
+	reflect.TypeOf((*UnixContext)(nil)):      true,
+	reflect.TypeOf((*net.Conn)(nil)).Elem():  true,
+	reflect.TypeOf((*WebSocketContext)(nil)): true,
+	reflect.TypeOf((*websocket.Conn)(nil)):   true,
+}
+
+// isContextParam returns true for the types of the last parameter which
+// are filled by the ArgsFixer of the services
+func isContextParam(t reflect.Type) bool {
+	return contextParamTypes[t]
+}
+
+// typeName returns the name of t, in which the struct types are replaced
+// by their class names
+func (d *describer) typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + d.typeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + d.typeName(t.Elem())
+	case reflect.Array:
+		return "[" + strconv.Itoa(t.Len()) + "]" + d.typeName(t.Elem())
+	case reflect.Map:
+		return "map[" + d.typeName(t.Key()) + "]" + d.typeName(t.Elem())
+	case reflect.Struct:
+		if builtinStructTypes[t] || t.Name() == "" {
+			return t.String()
+		}
+		return d.describeClass(t)
+	}
+	return t.String()
+}
+
+func (d *describer) describeClass(t reflect.Type) string {
+	if class, ok := d.classes[t]; ok {
+		return class.Name
+	}
+	name := ClassManager.GetClassAlias(t)
+	if name == "" {
+		name = t.Name()
+	}
+	class := &ClassDescription{Name: name}
+	d.classes[t] = class
+	fields := getFieldCache(t).fields
+	class.Fields = make([]FieldDescription, len(fields))
+	for i, f := range fields {
+		class.Fields[i] = FieldDescription{f.Name, d.typeName(t.FieldByIndex(f.Index).Type)}
+	}
+	return name
+}
@@ -0,0 +1,557 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/encryption_filter.go                            *
+ *                                                        *
+ * hprose encryption filter for Go.                       *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// The ciphers of the encryption filters, the other ciphers (such as
+// ChaCha20-Poly1305 of golang.org/x/crypto) can be registered by
+// RegisterCipher.
+const (
+	CipherAESGCM           byte = 1
+	CipherChaCha20Poly1305 byte = 2
+)
+
+// encryptionMagic starts the frames of the encryption filters, it is never
+// the first byte of the hprose, JSON or MessagePack data, nor the header of
+// CompressionFilter.
+const encryptionMagic = 0xc2
+
+// The frame types of the encryption filters
+const (
+	encryptionHandshake = 'H'
+	encryptionAccept    = 'A'
+	encryptionData      = 'D'
+	encryptionError     = 'E'
+)
+
+// encryptionDataHeader is the size of the header of the data frame:
+// the magic, the frame type and the sequence number.
+const encryptionDataHeader = 10
+
+// encryptionContextKey is the key of the encryption state of the request
+const encryptionContextKey = "hprose.encryption"
+
+// encryptionRequestKey is the key of the plain request in the client
+// context, it is resent when the service has no session for the connection
+const encryptionRequestKey = "hprose.encryption.request"
+
+// The errors of the encryption filters
+var (
+	ErrEncryptionRequired  = errors.New("The encryption is required.")
+	ErrEncryptionNoSession = errors.New("The encryption session isn't established.")
+	ErrEncryptionKeys      = errors.New("The encryption keys mismatch.")
+	ErrEncryptionReplay    = errors.New("The encrypted message is replayed.")
+	ErrEncryptionCipher    = errors.New("The cipher isn't supported.")
+	ErrEncryptionConn      = errors.New("The encryption requires a stream or websocket connection.")
+)
+
+var encryptionErrors = []error{
+	ErrEncryptionRequired,
+	ErrEncryptionNoSession,
+	ErrEncryptionKeys,
+	ErrEncryptionReplay,
+	ErrEncryptionCipher,
+	ErrEncryptionConn,
+}
+
+var ciphers = struct {
+	sync.RWMutex
+	m map[byte]func(key []byte) (cipher.AEAD, error)
+}{m: map[byte]func(key []byte) (cipher.AEAD, error){
+	CipherAESGCM: newAESGCM,
+}}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// RegisterCipher registers the AEAD constructor of the cipher, the key
+// passed to newAEAD is 32 bytes. The cipher must be registered in the
+// client and the service.
+func RegisterCipher(id byte, newAEAD func(key []byte) (cipher.AEAD, error)) {
+	ciphers.Lock()
+	ciphers.m[id] = newAEAD
+	ciphers.Unlock()
+}
+
+func getCipher(id byte) func(key []byte) (cipher.AEAD, error) {
+	ciphers.RLock()
+	defer ciphers.RUnlock()
+	return ciphers.m[id]
+}
+
+// encryptionKeys derives the keys of both directions and the key of the
+// handshake confirmation from the shared secret and the pre-shared key by
+// HKDF-SHA256, the salt binds the keys to the cipher and the public keys of
+// the handshake. The X25519 exchange isn't authenticated by itself, the
+// pre-shared key makes the keys of a man in the middle mismatch.
+func encryptionKeys(secret, psk []byte, id byte, clientKey, serverKey []byte) (send, recv, confirm []byte) {
+	salt := sha256.New()
+	salt.Write([]byte("hprose encryption"))
+	salt.Write([]byte{id})
+	salt.Write(clientKey)
+	salt.Write(serverKey)
+	extract := hmac.New(sha256.New, salt.Sum(nil))
+	extract.Write(secret)
+	extract.Write(psk)
+	prk := extract.Sum(nil)
+	expand := func(info string) []byte {
+		mac := hmac.New(sha256.New, prk)
+		mac.Write([]byte(info))
+		mac.Write([]byte{1})
+		return mac.Sum(nil)
+	}
+	return expand("client to server"), expand("server to client"), expand("confirm")
+}
+
+func encryptionConfirm(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("hprose encryption confirm"))
+	return mac.Sum(nil)
+}
+
+// encryptionSession is the established session of a connection, every
+// message is sealed with a new sequence number which is the nonce, the
+// received sequence numbers are checked in a sliding window to reject the
+// replayed messages.
+type encryptionSession struct {
+	seq    uint64
+	send   cipher.AEAD
+	recv   cipher.AEAD
+	mutex  sync.Mutex
+	top    uint64
+	window uint64
+}
+
+func newEncryptionSession(id byte, sendKey, recvKey []byte) (*encryptionSession, error) {
+	newAEAD := getCipher(id)
+	if newAEAD == nil {
+		return nil, ErrEncryptionCipher
+	}
+	send, err := newAEAD(sendKey)
+	if err != nil {
+		return nil, err
+	}
+	recv, err := newAEAD(recvKey)
+	if err != nil {
+		return nil, err
+	}
+	if send.NonceSize() < 8 {
+		return nil, ErrEncryptionCipher
+	}
+	return &encryptionSession{send: send, recv: recv}, nil
+}
+
+func encryptionNonce(aead cipher.AEAD, seq uint64) []byte {
+	nonce := make([]byte, aead.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], seq)
+	return nonce
+}
+
+func (session *encryptionSession) seal(data []byte) []byte {
+	seq := atomic.AddUint64(&session.seq, 1)
+	header := make([]byte, encryptionDataHeader, encryptionDataHeader+len(data)+session.send.Overhead())
+	header[0] = encryptionMagic
+	header[1] = encryptionData
+	binary.BigEndian.PutUint64(header[2:], seq)
+	return session.send.Seal(header, encryptionNonce(session.send, seq), data, header)
+}
+
+func (session *encryptionSession) open(data []byte) ([]byte, error) {
+	if len(data) < encryptionDataHeader {
+		return nil, ErrEncryptionKeys
+	}
+	seq := binary.BigEndian.Uint64(data[2:])
+	header := data[:encryptionDataHeader]
+	result, err := session.recv.Open(nil, encryptionNonce(session.recv, seq), data[encryptionDataHeader:], header)
+	if err != nil {
+		return nil, ErrEncryptionKeys
+	}
+	if !session.accept(seq) {
+		return nil, ErrEncryptionReplay
+	}
+	return result, nil
+}
+
+// accept marks the sequence number as received, it returns false when the
+// sequence number is received already or is too old to be checked.
+func (session *encryptionSession) accept(seq uint64) bool {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+	if seq == 0 {
+		return false
+	}
+	if seq > session.top {
+		shift := seq - session.top
+		if shift >= 64 {
+			session.window = 0
+		} else {
+			session.window <<= shift
+		}
+		session.window |= 1
+		session.top = seq
+		return true
+	}
+	diff := session.top - seq
+	if diff >= 64 || session.window&(1<<diff) != 0 {
+		return false
+	}
+	session.window |= 1 << diff
+	return true
+}
+
+func encryptionErrorFrame(err error) []byte {
+	return append([]byte{encryptionMagic, encryptionError}, err.Error()...)
+}
+
+func encryptionFrameError(data []byte) error {
+	message := string(data[2:])
+	for _, err := range encryptionErrors {
+		if err.Error() == message {
+			return err
+		}
+	}
+	return errors.New(message)
+}
+
+// EncryptionClientFilter encrypts the requests and decrypts the responses
+// by the session established with EncryptionServiceFilter.
+//
+// The session is established by an X25519 handshake over the connection
+// before the first request, so the client must send all the requests over
+// one connection: the stream client in the full duplex mode or the
+// websocket client. EncryptionClientFilter must be the last filter of the
+// client, and EncryptionServiceFilter must be the last filter of the
+// service.
+//
+// The session is dropped when the service has no session for the connection
+// (such as after the client reconnects), then a new session is established
+// and the request is resent once.
+//
+// The handshake is authenticated by the pre-shared key of the client and
+// the service, the handshake with a different key fails with
+// ErrEncryptionKeys.
+type EncryptionClientFilter struct {
+	Cipher  byte
+	PSK     []byte
+	mutex   sync.Mutex
+	session *encryptionSession
+}
+
+// NewEncryptionClientFilter is the constructor of EncryptionClientFilter,
+// psk is the pre-shared key which is required
+func NewEncryptionClientFilter(id byte, psk []byte) *EncryptionClientFilter {
+	if getCipher(id) == nil {
+		panic("The cipher isn't registered.")
+	}
+	if len(psk) == 0 {
+		panic("The pre-shared key can't be empty.")
+	}
+	return &EncryptionClientFilter{Cipher: id, PSK: psk}
+}
+
+// Reset drops the session, the next request establishes a new one
+func (filter *EncryptionClientFilter) Reset() {
+	filter.mutex.Lock()
+	filter.session = nil
+	filter.mutex.Unlock()
+}
+
+func (filter *EncryptionClientFilter) reset(session *encryptionSession) {
+	filter.mutex.Lock()
+	if filter.session == session {
+		filter.session = nil
+	}
+	filter.mutex.Unlock()
+}
+
+func (filter *EncryptionClientFilter) getSession(context *ClientContext) (*encryptionSession, error) {
+	filter.mutex.Lock()
+	defer filter.mutex.Unlock()
+	if filter.session != nil {
+		return filter.session, nil
+	}
+	if client, ok := context.Client.(interface {
+		FullDuplex() bool
+	}); ok && !client.FullDuplex() {
+		return nil, errors.New("EncryptionClientFilter requires the full duplex mode of the stream client.")
+	}
+	session, err := filter.handshake(context)
+	if err != nil {
+		return nil, err
+	}
+	filter.session = session
+	return session, nil
+}
+
+func (filter *EncryptionClientFilter) handshake(context *ClientContext) (*encryptionSession, error) {
+	if len(filter.PSK) == 0 {
+		return nil, ErrEncryptionKeys
+	}
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	clientKey := key.PublicKey().Bytes()
+	request := append([]byte{encryptionMagic, encryptionHandshake, filter.Cipher}, clientKey...)
+	response, err := filter.sendAndReceive(context, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(response) > 2 && response[0] == encryptionMagic && response[1] == encryptionError {
+		return nil, encryptionFrameError(response)
+	}
+	if len(response) != 2+len(clientKey)+sha256.Size ||
+		response[0] != encryptionMagic || response[1] != encryptionAccept {
+		return nil, errors.New("Wrong Response: \r\n" + string(response))
+	}
+	serverKey := response[2 : 2+len(clientKey)]
+	peer, err := ecdh.X25519().NewPublicKey(serverKey)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := key.ECDH(peer)
+	if err != nil {
+		return nil, err
+	}
+	send, recv, confirm := encryptionKeys(secret, filter.PSK, filter.Cipher, clientKey, serverKey)
+	if !hmac.Equal(response[2+len(clientKey):], encryptionConfirm(confirm)) {
+		return nil, ErrEncryptionKeys
+	}
+	return newEncryptionSession(filter.Cipher, send, recv)
+}
+
+func (filter *EncryptionClientFilter) sendAndReceive(clientContext *ClientContext, data []byte) ([]byte, error) {
+	if client, ok := clientContext.Client.(interface {
+		transfer(request []byte, context *ClientContext) ([]byte, error)
+	}); ok {
+		return client.transfer(data, clientContext)
+	}
+	return nil, errors.New("EncryptionClientFilter can't send the handshake by the client.")
+}
+
+// resend establishes a new session and resends the request once, the
+// service which has no session for the connection (such as a new connection
+// after the client reconnects) rejects the request before invoking it.
+func (filter *EncryptionClientFilter) resend(context Context) (*encryptionSession, []byte, error) {
+	clientContext, ok := context.(*ClientContext)
+	if !ok {
+		return nil, nil, ErrEncryptionNoSession
+	}
+	value, _ := context.GetInterface(encryptionRequestKey)
+	request, _ := value.([]byte)
+	session, err := filter.getSession(clientContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	context.SetInterface(encryptionContextKey, session)
+	data, err := filter.sendAndReceive(clientContext, session.seal(request))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < 2 || data[0] != encryptionMagic {
+		return nil, nil, ErrEncryptionRequired
+	}
+	if data[1] == encryptionError {
+		filter.reset(session)
+		return nil, nil, encryptionFrameError(data)
+	}
+	return session, data, nil
+}
+
+// InputFilter decrypts the response
+func (filter *EncryptionClientFilter) InputFilter(data []byte, context Context) []byte {
+	value, _ := context.GetInterface(encryptionContextKey)
+	session, _ := value.(*encryptionSession)
+	if session == nil || len(data) < 2 || data[0] != encryptionMagic {
+		panic(ErrEncryptionRequired)
+	}
+	if data[1] == encryptionError {
+		filter.reset(session)
+		err := encryptionFrameError(data)
+		if err != ErrEncryptionNoSession {
+			panic(err)
+		}
+		if session, data, err = filter.resend(context); err != nil {
+			panic(err)
+		}
+	}
+	result, err := session.open(data)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
+// OutputFilter establishes the session if needed and encrypts the request
+func (filter *EncryptionClientFilter) OutputFilter(data []byte, context Context) []byte {
+	clientContext, ok := context.(*ClientContext)
+	if !ok {
+		panic("EncryptionClientFilter is a client filter.")
+	}
+	session, err := filter.getSession(clientContext)
+	if err != nil {
+		panic(err)
+	}
+	context.SetInterface(encryptionContextKey, session)
+	context.SetInterface(encryptionRequestKey, data)
+	return session.seal(data)
+}
+
+// encryptionState is the state of the request in the service context
+type encryptionState struct {
+	session *encryptionSession
+	reply   []byte
+}
+
+// EncryptionServiceFilter decrypts the requests and encrypts the responses
+// by the session of the connection, see EncryptionClientFilter.
+//
+// The session is stored in the ConnData of StreamContext or
+// WebSocketContext, so it is dropped when the connection is closed. The
+// requests without encryption get the hprose error response, the failed
+// handshakes and the messages which can't be decrypted get the error frame.
+// The clients with a different pre-shared key can't verify the confirmation
+// of the handshake, and their messages can't be decrypted.
+type EncryptionServiceFilter struct {
+	PSK []byte
+}
+
+// NewEncryptionServiceFilter is the constructor of EncryptionServiceFilter,
+// psk is the pre-shared key which is required
+func NewEncryptionServiceFilter(psk []byte) *EncryptionServiceFilter {
+	if len(psk) == 0 {
+		panic("The pre-shared key can't be empty.")
+	}
+	return &EncryptionServiceFilter{PSK: psk}
+}
+
+func getConnData(context Context) *sync.Map {
+	switch context := context.(type) {
+	case *StreamContext:
+		return context.ConnData
+	case *WebSocketContext:
+		return context.ConnData
+	}
+	return nil
+}
+
+func (filter *EncryptionServiceFilter) reply(context Context, reply []byte) []byte {
+	context.SetInterface(encryptionContextKey, &encryptionState{reply: reply})
+	return []byte{TagEnd}
+}
+
+func (filter *EncryptionServiceFilter) handshake(data []byte, connData *sync.Map) ([]byte, error) {
+	if len(data) < 3 || len(filter.PSK) == 0 {
+		return nil, ErrEncryptionKeys
+	}
+	id := data[2]
+	if getCipher(id) == nil {
+		return nil, ErrEncryptionCipher
+	}
+	clientKey := data[3:]
+	peer, err := ecdh.X25519().NewPublicKey(clientKey)
+	if err != nil {
+		return nil, ErrEncryptionKeys
+	}
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := key.ECDH(peer)
+	if err != nil {
+		return nil, ErrEncryptionKeys
+	}
+	serverKey := key.PublicKey().Bytes()
+	recv, send, confirm := encryptionKeys(secret, filter.PSK, id, clientKey, serverKey)
+	session, err := newEncryptionSession(id, send, recv)
+	if err != nil {
+		return nil, err
+	}
+	connData.Store(encryptionContextKey, session)
+	reply := append([]byte{encryptionMagic, encryptionAccept}, serverKey...)
+	return append(reply, encryptionConfirm(confirm)...), nil
+}
+
+// InputFilter handles the handshake and decrypts the request
+func (filter *EncryptionServiceFilter) InputFilter(data []byte, context Context) []byte {
+	if len(data) < 2 || data[0] != encryptionMagic {
+		buf := new(bytes.Buffer)
+		writeError(NewWriter(buf, true), ErrEncryptionRequired)
+		buf.WriteByte(TagEnd)
+		return filter.reply(context, buf.Bytes())
+	}
+	connData := getConnData(context)
+	if connData == nil {
+		return filter.reply(context, encryptionErrorFrame(ErrEncryptionConn))
+	}
+	switch data[1] {
+	case encryptionHandshake:
+		reply, err := filter.handshake(data, connData)
+		if err != nil {
+			return filter.reply(context, encryptionErrorFrame(err))
+		}
+		return filter.reply(context, reply)
+	case encryptionData:
+		value, _ := connData.Load(encryptionContextKey)
+		session, _ := value.(*encryptionSession)
+		if session == nil {
+			return filter.reply(context, encryptionErrorFrame(ErrEncryptionNoSession))
+		}
+		result, err := session.open(data)
+		if err != nil {
+			return filter.reply(context, encryptionErrorFrame(err))
+		}
+		context.SetInterface(encryptionContextKey, &encryptionState{session: session})
+		return result
+	}
+	return filter.reply(context, encryptionErrorFrame(ErrEncryptionRequired))
+}
+
+// OutputFilter encrypts the response, or replaces it with the reply of the
+// handshake or the error
+func (filter *EncryptionServiceFilter) OutputFilter(data []byte, context Context) []byte {
+	value, _ := context.GetInterface(encryptionContextKey)
+	state, _ := value.(*encryptionState)
+	switch {
+	case state == nil:
+		return data
+	case state.reply != nil:
+		return state.reply
+	}
+	return state.session.seal(data)
+}
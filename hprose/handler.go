@@ -0,0 +1,76 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/handler.go                                      *
+ *                                                        *
+ * hprose handler for Go.                                 *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"reflect"
+)
+
+// NextInvokeHandler is the rest of the invoke handler chain
+type NextInvokeHandler func(name string, args []reflect.Value, context Context) (results []reflect.Value, err error)
+
+// InvokeHandler is the invoke middleware, it is called with the decoded
+// arguments and returns the results of the call. It can call next to go on
+// with the call, change the arguments and the results, or return without
+// calling next.
+type InvokeHandler func(name string, args []reflect.Value, context Context, next NextInvokeHandler) (results []reflect.Value, err error)
+
+type invokeHandlers []InvokeHandler
+
+// handler returns the chain which calls the handlers in order and then the
+// last handler
+func (handlers invokeHandlers) handler(last NextInvokeHandler) NextInvokeHandler {
+	next := last
+	for i := len(handlers) - 1; i >= 0; i-- {
+		handler, n := handlers[i], next
+		next = func(name string, args []reflect.Value, context Context) ([]reflect.Value, error) {
+			return handler(name, args, context, n)
+		}
+	}
+	return next
+}
+
+// NextFilterHandler is the rest of the filter handler chain
+type NextFilterHandler func(request []byte, context Context) (response []byte, err error)
+
+// FilterHandler is the I/O middleware, it is called with the request data
+// and returns the response data of the whole exchange. It can call next to
+// go on with the exchange, change the request and the response, or return
+// without calling next.
+//
+// The before filter handlers get the data before the client output filters
+// and the service input filters, the after filter handlers get the data
+// after them, so the client after filter handlers wrap the transport.
+type FilterHandler func(request []byte, context Context, next NextFilterHandler) (response []byte, err error)
+
+type filterHandlers []FilterHandler
+
+// handler returns the chain which calls the handlers in order and then the
+// last handler
+func (handlers filterHandlers) handler(last NextFilterHandler) NextFilterHandler {
+	next := last
+	for i := len(handlers) - 1; i >= 0; i-- {
+		handler, n := handlers[i], next
+		next = func(request []byte, context Context) ([]byte, error) {
+			return handler(request, context, n)
+		}
+	}
+	return next
+}
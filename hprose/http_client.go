@@ -12,7 +12,7 @@
  *                                                        *
  * hprose http client for Go.                             *
  *                                                        *
- * LastModified: May 24, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -20,6 +20,7 @@
 package hprose
 
 import (
+	"context"
 	"crypto/tls"
 	"io"
 	"io/ioutil"
@@ -73,6 +74,17 @@ func (client *HttpClient) SetUri(uri string) {
 	client.BaseClient.SetUri(uri)
 }
 
+// SetCodec set the codec of hprose client, the requests are sent with the
+// content type of the codec
+func (client *HttpClient) SetCodec(codec Codec) {
+	client.codec = codec
+	if codec == nil {
+		client.Header().Del("Content-Type")
+	} else {
+		client.Header().Set("Content-Type", codec.ContentType())
+	}
+}
+
 // Http return the http.Client in hprose client
 func (client *HttpClient) Http() *http.Client {
 	return client.Transporter.(*httpTransporter).Client
@@ -141,7 +153,8 @@ func newHttpTransporter() (trans *httpTransporter) {
 	client.Transport = tr
 	trans = new(httpTransporter)
 	trans.Client = client
-	trans.Header = new(http.Header)
+	header := make(http.Header)
+	trans.Header = &header
 	return
 }
 
@@ -159,24 +172,55 @@ func (h *httpTransporter) readAll(response *http.Response) (data []byte, err err
 
 // SendAndReceive send and receive the data
 func (h *httpTransporter) SendAndReceive(uri string, data []byte) ([]byte, error) {
+	return h.SendAndReceiveContext(backgroundContext, uri, data)
+}
+
+// SendAndReceiveContext send and receive the data,
+// the http request is canceled when the ctx is done
+func (h *httpTransporter) SendAndReceiveContext(ctx context.Context, uri string, data []byte) ([]byte, error) {
 	req, err := http.NewRequest("POST", uri, NewBytesReader(data))
 	if err != nil {
 		return nil, err
 	}
+	req = req.WithContext(ctx)
 	for key, values := range *h.Header {
 		for _, value := range values {
 			req.Header.Add(key, value)
 		}
 	}
 	req.ContentLength = int64(len(data))
-	req.Header.Set("Content-Type", "application/hprose")
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/hprose")
+	}
 	resp, err := h.Do(req)
 	if err != nil {
+		if e := ctx.Err(); e != nil {
+			return nil, e
+		}
 		return nil, err
 	}
 	data, err = ioutil.ReadAll(resp.Body)
 	if err != nil {
+		resp.Body.Close()
+		if e := ctx.Err(); e != nil {
+			return nil, e
+		}
 		return nil, err
 	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, &HttpStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
 	return data, resp.Body.Close()
 }
+
+// HttpStatusError is returned when the http status of the response isn't 2xx
+type HttpStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+// Error return the error message
+func (e *HttpStatusError) Error() string {
+	return "Wrong Response: " + e.Status
+}
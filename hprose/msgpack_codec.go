@@ -0,0 +1,1051 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/msgpack_codec.go                                *
+ *                                                        *
+ * hprose msgpack codec for Go.                           *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MsgPackCodec is the MessagePack codec.
+//
+// The requests and the responses are MessagePack-RPC messages: the request
+// is [0, msgid, method, params], the notification (the Oneway call) is
+// [2, method, params] and the response is [1, msgid, error, result]. The
+// batch is the sequence of the messages. The calls with ByRef have true as
+// the fifth element of the request and get the arguments as the fifth
+// element of the response. The error is a map of code, message and data.
+//
+// The structs are maps keyed by the field aliases like the hprose format,
+// so the tag registered by ClassManager.Register is used, and time.Time is
+// the timestamp extension.
+type MsgPackCodec struct {
+	id int64
+}
+
+// NewMsgPackCodec is the constructor of MsgPackCodec
+func NewMsgPackCodec() *MsgPackCodec {
+	return new(MsgPackCodec)
+}
+
+const (
+	msgpackRequest      = 0
+	msgpackResponse     = 1
+	msgpackNotification = 2
+	msgpackTimestamp    = -1
+)
+
+// ContentType of MsgPackCodec
+func (codec *MsgPackCodec) ContentType() string {
+	return "application/msgpack"
+}
+
+// Marshal encodes v to MessagePack
+func (codec *MsgPackCodec) Marshal(v reflect.Value) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := msgpackEncode(buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes the MessagePack data into v
+func (codec *MsgPackCodec) Unmarshal(data []byte, v reflect.Value) error {
+	return (&msgpackReader{data: data}).decode(v)
+}
+
+func msgpackEncode(buf *bytes.Buffer, v reflect.Value) error {
+	if !v.IsValid() {
+		return buf.WriteByte(0xc0)
+	}
+	t := v.Type()
+	if t == timeType {
+		msgpackWriteTime(buf, v.Interface().(time.Time))
+		return nil
+	}
+	if t.Implements(textMarshalerType) || v.CanAddr() && reflect.PtrTo(t).Implements(textMarshalerType) {
+		if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface) && v.IsNil() {
+			return buf.WriteByte(0xc0)
+		}
+		if v.CanAddr() {
+			v = v.Addr()
+		}
+		text, err := v.Interface().(interface {
+			MarshalText() ([]byte, error)
+		}).MarshalText()
+		if err != nil {
+			return err
+		}
+		msgpackWriteString(buf, string(text))
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return buf.WriteByte(0xc3)
+		}
+		return buf.WriteByte(0xc2)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		msgpackWriteInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		msgpackWriteUint(buf, v.Uint())
+	case reflect.Float32:
+		buf.WriteByte(0xca)
+		msgpackWriteBytes(buf, uint64(math.Float32bits(float32(v.Float()))), 4)
+	case reflect.Float64:
+		buf.WriteByte(0xcb)
+		msgpackWriteBytes(buf, math.Float64bits(v.Float()), 8)
+	case reflect.String:
+		msgpackWriteString(buf, v.String())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return buf.WriteByte(0xc0)
+		}
+		return msgpackEncode(buf, v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return buf.WriteByte(0xc0)
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			msgpackWriteBinary(buf, v.Bytes())
+			return nil
+		}
+		return msgpackEncodeList(buf, v)
+	case reflect.Array:
+		return msgpackEncodeList(buf, v)
+	case reflect.Map:
+		if v.IsNil() {
+			return buf.WriteByte(0xc0)
+		}
+		msgpackWriteHeader(buf, 0x80, 0xde, v.Len())
+		for _, key := range v.MapKeys() {
+			if err := msgpackEncode(buf, key); err != nil {
+				return err
+			}
+			if err := msgpackEncode(buf, v.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return msgpackEncodeStruct(buf, v)
+	default:
+		return errors.New("the type " + t.String() + " isn't supported by MsgPackCodec")
+	}
+	return nil
+}
+
+func msgpackEncodeList(buf *bytes.Buffer, v reflect.Value) error {
+	n := v.Len()
+	msgpackWriteHeader(buf, 0x90, 0xdc, n)
+	for i := 0; i < n; i++ {
+		if err := msgpackEncode(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func msgpackEncodeStruct(buf *bytes.Buffer, v reflect.Value) error {
+	fields := getFieldCache(v.Type()).fields
+	values := make([]reflect.Value, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if fv, ok := fieldByIndex(v, f.Index, false); ok {
+			values = append(values, fv)
+			names = append(names, f.Name)
+		}
+	}
+	msgpackWriteHeader(buf, 0x80, 0xde, len(values))
+	for i := range values {
+		msgpackWriteString(buf, names[i])
+		if err := msgpackEncode(buf, values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// msgpackWriteBytes writes the n low bytes of u in big-endian
+func msgpackWriteBytes(buf *bytes.Buffer, u uint64, n int) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], u)
+	buf.Write(b[8-n:])
+}
+
+func msgpackWriteInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0:
+		msgpackWriteUint(buf, uint64(i))
+	case i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		msgpackWriteBytes(buf, uint64(i), 1)
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		msgpackWriteBytes(buf, uint64(i), 2)
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		msgpackWriteBytes(buf, uint64(i), 4)
+	default:
+		buf.WriteByte(0xd3)
+		msgpackWriteBytes(buf, uint64(i), 8)
+	}
+}
+
+func msgpackWriteUint(buf *bytes.Buffer, u uint64) {
+	switch {
+	case u <= 0x7f:
+		buf.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		msgpackWriteBytes(buf, u, 1)
+	case u <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		msgpackWriteBytes(buf, u, 2)
+	case u <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		msgpackWriteBytes(buf, u, 4)
+	default:
+		buf.WriteByte(0xcf)
+		msgpackWriteBytes(buf, u, 8)
+	}
+}
+
+func msgpackWriteString(buf *bytes.Buffer, s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xd9)
+		msgpackWriteBytes(buf, uint64(n), 1)
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xda)
+		msgpackWriteBytes(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xdb)
+		msgpackWriteBytes(buf, uint64(n), 4)
+	}
+	buf.WriteString(s)
+}
+
+func msgpackWriteBinary(buf *bytes.Buffer, b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf.WriteByte(0xc4)
+		msgpackWriteBytes(buf, uint64(n), 1)
+	case n <= math.MaxUint16:
+		buf.WriteByte(0xc5)
+		msgpackWriteBytes(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(0xc6)
+		msgpackWriteBytes(buf, uint64(n), 4)
+	}
+	buf.Write(b)
+}
+
+// msgpackWriteHeader writes the array or map header, fix is the tag of the
+// fixarray or fixmap, and tag16 is the tag of the array16 or map16.
+func msgpackWriteHeader(buf *bytes.Buffer, fix byte, tag16 byte, n int) {
+	switch {
+	case n < 16:
+		buf.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(tag16)
+		msgpackWriteBytes(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(tag16 + 1)
+		msgpackWriteBytes(buf, uint64(n), 4)
+	}
+}
+
+func msgpackWriteTime(buf *bytes.Buffer, t time.Time) {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+		buf.Write([]byte{0xd6, 0xff})
+		msgpackWriteBytes(buf, uint64(sec), 4)
+	case sec >= 0 && sec < 1<<34:
+		buf.Write([]byte{0xd7, 0xff})
+		msgpackWriteBytes(buf, uint64(nsec)<<34|uint64(sec), 8)
+	default:
+		buf.Write([]byte{0xc7, 12, 0xff})
+		msgpackWriteBytes(buf, uint64(nsec), 4)
+		msgpackWriteBytes(buf, uint64(sec), 8)
+	}
+}
+
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) read(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *msgpackReader) readByte() (byte, error) {
+	b, err := r.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// readUint reads n bytes as big-endian unsigned integer
+func (r *msgpackReader) readUint(n int) (uint64, error) {
+	b, err := r.read(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (r *msgpackReader) peekNil() bool {
+	if r.pos < len(r.data) && r.data[r.pos] == 0xc0 {
+		r.pos++
+		return true
+	}
+	return false
+}
+
+// readLen reads the array header when array is true, or the map header.
+func (r *msgpackReader) readLen(array bool) (int, error) {
+	b, err := r.readByte()
+	if err != nil {
+		return 0, err
+	}
+	var fix, tag16 byte = 0x80, 0xde
+	if array {
+		fix, tag16 = 0x90, 0xdc
+	}
+	var n uint64
+	switch {
+	case b&0xf0 == fix:
+		return int(b & 0x0f), nil
+	case b == tag16:
+		n, err = r.readUint(2)
+	case b == tag16+1:
+		n, err = r.readUint(4)
+	default:
+		if array {
+			return 0, fmt.Errorf("msgpack: 0x%x isn't an array", b)
+		}
+		return 0, fmt.Errorf("msgpack: 0x%x isn't a map", b)
+	}
+	return int(n), err
+}
+
+// readRaw returns the encoded data of the next value
+func (r *msgpackReader) readRaw() ([]byte, error) {
+	start := r.pos
+	if _, err := r.readInterface(); err != nil {
+		return nil, err
+	}
+	return r.data[start:r.pos], nil
+}
+
+func (r *msgpackReader) readInterface() (interface{}, error) {
+	b, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return r.readMap(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return r.readList(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return r.readString(int(b & 0x1f))
+	}
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.readUint(1 << (b - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.read(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, data...), nil
+	case 0xc7, 0xc8, 0xc9:
+		n, err := r.readUint(1 << (b - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return r.readExt(int(n))
+	case 0xca:
+		u, err := r.readUint(4)
+		return math.Float32frombits(uint32(u)), err
+	case 0xcb:
+		u, err := r.readUint(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := r.readUint(1 << (b - 0xcc))
+		if err != nil || u > math.MaxInt64 {
+			return u, err
+		}
+		return int64(u), nil
+	case 0xd0:
+		u, err := r.readUint(1)
+		return int64(int8(u)), err
+	case 0xd1:
+		u, err := r.readUint(2)
+		return int64(int16(u)), err
+	case 0xd2:
+		u, err := r.readUint(4)
+		return int64(int32(u)), err
+	case 0xd3:
+		u, err := r.readUint(8)
+		return int64(u), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return r.readExt(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.readUint(1 << (b - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.readString(int(n))
+	case 0xdc, 0xdd:
+		n, err := r.readUint(2 << (b - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.readList(int(n))
+	case 0xde, 0xdf:
+		n, err := r.readUint(2 << (b - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.readMap(int(n))
+	}
+	return nil, fmt.Errorf("msgpack: unknown tag 0x%x", b)
+}
+
+func (r *msgpackReader) readString(n int) (interface{}, error) {
+	data, err := r.read(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (r *msgpackReader) readList(n int) (interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	list := make([]interface{}, n)
+	for i := range list {
+		var err error
+		if list[i], err = r.readInterface(); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// readMap returns map[string]interface{} if all the keys are strings,
+// or map[interface{}]interface{}
+func (r *msgpackReader) readMap(n int) (interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, io.ErrUnexpectedEOF
+	}
+	m := make(map[interface{}]interface{}, n)
+	stringKeys := true
+	for i := 0; i < n; i++ {
+		key, err := r.readInterface()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := key.(string); !ok {
+			stringKeys = false
+		}
+		if m[key], err = r.readInterface(); err != nil {
+			return nil, err
+		}
+	}
+	if !stringKeys {
+		return m, nil
+	}
+	sm := make(map[string]interface{}, n)
+	for k, v := range m {
+		sm[k.(string)] = v
+	}
+	return sm, nil
+}
+
+func (r *msgpackReader) readExt(n int) (interface{}, error) {
+	t, err := r.readByte()
+	if err != nil {
+		return nil, err
+	}
+	data, err := r.read(n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(t) != msgpackTimestamp {
+		return nil, fmt.Errorf("msgpack: unknown extension type %d", int8(t))
+	}
+	switch n {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		u := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(u&(1<<34-1)), int64(u>>34)), nil
+	case 12:
+		nsec := binary.BigEndian.Uint32(data)
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(nsec)), nil
+	}
+	return nil, errors.New("msgpack: wrong timestamp")
+}
+
+func (r *msgpackReader) decode(v reflect.Value) (err error) {
+	if r.peekNil() {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	t := v.Type()
+	if t != timeType && v.CanAddr() && reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		var x interface{}
+		if x, err = r.readInterface(); err != nil {
+			return err
+		}
+		text, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("msgpack: can't decode %T into %s", x, t.String())
+		}
+		return v.Addr().Interface().(interface {
+			UnmarshalText([]byte) error
+		}).UnmarshalText([]byte(text))
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return r.decode(v.Elem())
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var n int
+		if n, err = r.readLen(true); err != nil {
+			return err
+		}
+		if n > len(r.data)-r.pos {
+			return io.ErrUnexpectedEOF
+		}
+		slice := reflect.MakeSlice(t, n, n)
+		for i := 0; i < n; i++ {
+			if err = r.decode(slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Array:
+		var n int
+		if n, err = r.readLen(true); err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			if i < v.Len() {
+				err = r.decode(v.Index(i))
+			} else {
+				_, err = r.readInterface()
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		var n int
+		if n, err = r.readLen(false); err != nil {
+			return err
+		}
+		if n > len(r.data)-r.pos {
+			return io.ErrUnexpectedEOF
+		}
+		m := reflect.MakeMapWithSize(t, n)
+		for i := 0; i < n; i++ {
+			key := reflect.New(t.Key()).Elem()
+			value := reflect.New(t.Elem()).Elem()
+			if err = r.decode(key); err != nil {
+				return err
+			}
+			if err = r.decode(value); err != nil {
+				return err
+			}
+			m.SetMapIndex(key, value)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Struct:
+		if t != timeType {
+			return r.decodeStruct(v)
+		}
+	}
+	x, err := r.readInterface()
+	if err != nil {
+		return err
+	}
+	return msgpackSetValue(v, x)
+}
+
+func (r *msgpackReader) decodeStruct(v reflect.Value) error {
+	n, err := r.readLen(false)
+	if err != nil {
+		return err
+	}
+	indexMap := getIndexCache(v.Type())
+	for i := 0; i < n; i++ {
+		var key interface{}
+		if key, err = r.readInterface(); err != nil {
+			return err
+		}
+		name, _ := key.(string)
+		if index, ok := indexMap[strings.ToLower(name)]; ok {
+			f, _ := fieldByIndex(v, index, true)
+			err = r.decode(f)
+		} else {
+			_, err = r.readInterface()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// msgpackSetValue sets the decoded value x to v
+func msgpackSetValue(v reflect.Value, x interface{}) error {
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Interface:
+		if x != nil {
+			xv := reflect.ValueOf(x)
+			if !xv.Type().Implements(t) {
+				break
+			}
+			v.Set(xv)
+		}
+		return nil
+	case reflect.Bool:
+		if b, ok := x.(bool); ok {
+			v.SetBool(b)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch n := x.(type) {
+		case int64:
+			v.SetInt(n)
+			return nil
+		case uint64:
+			v.SetInt(int64(n))
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch n := x.(type) {
+		case int64:
+			v.SetUint(uint64(n))
+			return nil
+		case uint64:
+			v.SetUint(n)
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := x.(type) {
+		case float32:
+			v.SetFloat(float64(n))
+			return nil
+		case float64:
+			v.SetFloat(n)
+			return nil
+		case int64:
+			v.SetFloat(float64(n))
+			return nil
+		case uint64:
+			v.SetFloat(float64(n))
+			return nil
+		}
+	case reflect.String:
+		switch s := x.(type) {
+		case string:
+			v.SetString(s)
+			return nil
+		case []byte:
+			v.SetString(string(s))
+			return nil
+		}
+	case reflect.Slice:
+		switch s := x.(type) {
+		case []byte:
+			v.SetBytes(s)
+			return nil
+		case string:
+			v.SetBytes([]byte(s))
+			return nil
+		}
+	case reflect.Struct:
+		if tm, ok := x.(time.Time); ok && t == timeType {
+			v.Set(reflect.ValueOf(tm))
+			return nil
+		}
+	}
+	return fmt.Errorf("msgpack: can't decode %T into %s", x, t.String())
+}
+
+// msgpackSplit splits the encoded array into the encoded values, nil is an
+// empty array
+func msgpackSplit(data []byte) ([][]byte, error) {
+	r := &msgpackReader{data: data}
+	if len(data) == 0 || r.peekNil() {
+		return nil, nil
+	}
+	n, err := r.readLen(true)
+	if err != nil {
+		return nil, err
+	}
+	if n > len(data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	values := make([][]byte, n)
+	for i := range values {
+		if values[i], err = r.readRaw(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func msgpackJoin(buf *bytes.Buffer, values [][]byte) {
+	msgpackWriteHeader(buf, 0x90, 0xdc, len(values))
+	for _, value := range values {
+		buf.Write(value)
+	}
+}
+
+// EncodeRequest encodes the calls as MessagePack-RPC requests, the calls
+// which aren't Oneway get the msgids
+func (codec *MsgPackCodec) EncodeRequest(calls []*CodecCall) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for _, call := range calls {
+		if call.Oneway {
+			msgpackWriteHeader(buf, 0x90, 0xdc, 3)
+			msgpackWriteInt(buf, msgpackNotification)
+		} else {
+			n := 4
+			if call.ByRef {
+				n = 5
+			}
+			id := atomic.AddInt64(&codec.id, 1)
+			call.ID = id
+			msgpackWriteHeader(buf, 0x90, 0xdc, n)
+			msgpackWriteInt(buf, msgpackRequest)
+			msgpackWriteInt(buf, id)
+		}
+		msgpackWriteString(buf, call.Name)
+		msgpackJoin(buf, call.Args)
+		if call.ByRef && !call.Oneway {
+			buf.WriteByte(0xc3)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeRequest decodes the MessagePack-RPC requests and notifications
+func (codec *MsgPackCodec) DecodeRequest(data []byte) ([]*CodecCall, error) {
+	r := &msgpackReader{data: data}
+	calls := make([]*CodecCall, 0, 1)
+	for r.pos < len(data) {
+		n, err := r.readLen(true)
+		if err != nil {
+			return nil, err
+		}
+		x, err := r.readInterface()
+		if err != nil {
+			return nil, err
+		}
+		call := &CodecCall{Results: 1}
+		count := 4
+		switch x {
+		case int64(msgpackRequest):
+			if n < 4 {
+				return nil, errors.New("msgpack: wrong request")
+			}
+			if call.ID, err = r.readRaw(); err != nil {
+				return nil, err
+			}
+		case int64(msgpackNotification):
+			if n < 3 {
+				return nil, errors.New("msgpack: wrong notification")
+			}
+			call.Oneway = true
+			count = 3
+		default:
+			return nil, fmt.Errorf("msgpack: wrong message type %v", x)
+		}
+		if x, err = r.readInterface(); err != nil {
+			return nil, err
+		}
+		var ok bool
+		if call.Name, ok = x.(string); !ok {
+			return nil, errors.New("msgpack: method must be a string")
+		}
+		if err = codec.decodeParams(r, call); err != nil {
+			return nil, err
+		}
+		if !call.Oneway && n > 4 {
+			if x, err = r.readInterface(); err != nil {
+				return nil, err
+			}
+			call.ByRef = x == true
+			count++
+		}
+		// skip the unknown elements
+		for i := count; i < n; i++ {
+			if _, err = r.readInterface(); err != nil {
+				return nil, err
+			}
+		}
+		calls = append(calls, call)
+	}
+	if len(calls) == 0 {
+		return nil, errors.New("msgpack: no request")
+	}
+	return calls, nil
+}
+
+// decodeParams decodes the params array, or the params map as the named
+// arguments
+func (codec *MsgPackCodec) decodeParams(r *msgpackReader, call *CodecCall) (err error) {
+	if r.peekNil() {
+		return nil
+	}
+	if r.pos < len(r.data) && (r.data[r.pos]&0xf0 == 0x80 || r.data[r.pos] == 0xde || r.data[r.pos] == 0xdf) {
+		start := r.pos
+		var n int
+		if n, err = r.readLen(false); err != nil {
+			return err
+		}
+		call.Named = make(map[string][]byte, n)
+		for i := 0; i < n; i++ {
+			var key interface{}
+			if key, err = r.readInterface(); err != nil {
+				return err
+			}
+			name, _ := key.(string)
+			if call.Named[name], err = r.readRaw(); err != nil {
+				return err
+			}
+		}
+		call.Args = [][]byte{r.data[start:r.pos]}
+		return nil
+	}
+	var raw []byte
+	if raw, err = r.readRaw(); err != nil {
+		return err
+	}
+	call.Args, err = msgpackSplit(raw)
+	return err
+}
+
+// EncodeResponse encodes the replies as MessagePack-RPC responses, the
+// Oneway calls get no response
+func (codec *MsgPackCodec) EncodeResponse(calls []*CodecCall, replies []*CodecReply) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	for i, call := range calls {
+		if call.Oneway {
+			continue
+		}
+		reply := replies[i]
+		if call.ByRef && reply.Error == nil {
+			msgpackWriteHeader(buf, 0x90, 0xdc, 5)
+		} else {
+			msgpackWriteHeader(buf, 0x90, 0xdc, 4)
+		}
+		msgpackWriteInt(buf, msgpackResponse)
+		if id, ok := call.ID.([]byte); ok {
+			buf.Write(id)
+		} else {
+			buf.WriteByte(0xc0)
+		}
+		if e := reply.Error; e != nil {
+			details := map[string]interface{}{"message": e.Message}
+			if e.Code != 0 {
+				details["code"] = e.Code
+			}
+			if e.Data != nil {
+				details["data"] = e.Data
+			}
+			if err := msgpackEncode(buf, reflect.ValueOf(details)); err != nil {
+				return nil, err
+			}
+			buf.WriteByte(0xc0)
+			continue
+		}
+		buf.WriteByte(0xc0)
+		switch len(reply.Results) {
+		case 0:
+			buf.WriteByte(0xc0)
+		case 1:
+			buf.Write(reply.Results[0])
+		default:
+			msgpackJoin(buf, reply.Results)
+		}
+		if call.ByRef {
+			msgpackJoin(buf, reply.Args)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeResponse decodes the MessagePack-RPC responses, the responses are
+// matched to the calls by the msgids
+func (codec *MsgPackCodec) DecodeResponse(data []byte, calls []*CodecCall) ([]*CodecReply, error) {
+	r := &msgpackReader{data: data}
+	replies := make(map[string]*CodecReply)
+	var failure *CodecReply
+	for r.pos < len(data) {
+		n, err := r.readLen(true)
+		if err != nil {
+			return nil, err
+		}
+		if n < 4 {
+			return nil, errors.New("msgpack: wrong response")
+		}
+		if x, err := r.readInterface(); err != nil || x != int64(msgpackResponse) {
+			return nil, fmt.Errorf("msgpack: wrong message type %v", x)
+		}
+		id, err := r.readInterface()
+		if err != nil {
+			return nil, err
+		}
+		reply := new(CodecReply)
+		if reply.Error, err = r.readError(); err != nil {
+			return nil, err
+		}
+		var result []byte
+		if result, err = r.readRaw(); err != nil {
+			return nil, err
+		}
+		reply.Results = [][]byte{result}
+		if n > 4 {
+			var args []byte
+			if args, err = r.readRaw(); err != nil {
+				return nil, err
+			}
+			reply.Args = [][]byte{args}
+		}
+		for i := 5; i < n; i++ {
+			if _, err = r.readInterface(); err != nil {
+				return nil, err
+			}
+		}
+		if id == nil {
+			failure = reply
+		} else {
+			replies[fmt.Sprint(id)] = reply
+		}
+	}
+	results := make([]*CodecReply, len(calls))
+	for i, call := range calls {
+		if call.Oneway {
+			results[i] = new(CodecReply)
+			continue
+		}
+		id := strconv.FormatInt(call.ID.(int64), 10)
+		reply, ok := replies[id]
+		if !ok {
+			if reply = failure; reply == nil {
+				return nil, errors.New("Wrong Response: no response for msgid " + id)
+			}
+		}
+		if reply.Error == nil {
+			var err error
+			if call.Results != 1 {
+				if reply.Results, err = msgpackSplit(reply.Results[0]); err != nil {
+					return nil, err
+				}
+			}
+			if reply.Args != nil {
+				if reply.Args, err = msgpackSplit(reply.Args[0]); err != nil {
+					return nil, err
+				}
+			}
+		}
+		results[i] = reply
+	}
+	return results, nil
+}
+
+// readError reads the error of the response, it is nil, a string message or
+// a map of code, message and data.
+func (r *msgpackReader) readError() (*RemoteError, error) {
+	x, err := r.readInterface()
+	if err != nil || x == nil {
+		return nil, err
+	}
+	switch e := x.(type) {
+	case string:
+		return &RemoteError{Message: e}, nil
+	case map[string]interface{}:
+		re := new(RemoteError)
+		re.Message, _ = e["message"].(string)
+		if code, ok := e["code"].(int64); ok {
+			re.Code = int(code)
+		}
+		re.Data = e["data"]
+		return re, nil
+	}
+	return &RemoteError{Message: fmt.Sprint(x)}, nil
+}
@@ -0,0 +1,132 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/remote_error.go                                 *
+ *                                                        *
+ * hprose remote error for Go.                            *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// RemoteError is the error returned by the remote service.
+//
+// The service sends the Code, Stack and Data after the error message only
+// when they are present, and appends the Stack to the error message too, so
+// the clients which don't know them still get the error message and the
+// stack. The client removes the Stack from the error message.
+type RemoteError struct {
+	Code    int
+	Message string
+	Stack   string
+	Data    interface{}
+}
+
+// Error return the error message
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+// CodeError is the error which has an error code,
+// the service sends the code to the client in RemoteError
+type CodeError interface {
+	error
+	Code() int
+}
+
+// DataError is the error which has the detail data,
+// the service sends the data to the client in RemoteError
+type DataError interface {
+	error
+	Data() interface{}
+}
+
+func newRemoteError(err error) *RemoteError {
+	var re *RemoteError
+	if errors.As(err, &re) {
+		return re
+	}
+	e := &RemoteError{Message: err.Error()}
+	var c CodeError
+	if errors.As(err, &c) {
+		e.Code = c.Code()
+	}
+	var d DataError
+	if errors.As(err, &d) {
+		e.Data = d.Data()
+	}
+	return e
+}
+
+func writeError(writer *Writer, err error) (e error) {
+	re := newRemoteError(err)
+	if e = writer.Stream.WriteByte(TagError); e != nil {
+		return e
+	}
+	message := re.Message
+	if re.Stack != "" {
+		message += "\r\n" + re.Stack
+	}
+	if e = writer.WriteString(message); e != nil {
+		return e
+	}
+	if re.Code == 0 && re.Stack == "" && re.Data == nil {
+		return nil
+	}
+	details := make(map[string]interface{})
+	if re.Code != 0 {
+		details["code"] = re.Code
+	}
+	if re.Stack != "" {
+		details["stack"] = re.Stack
+	}
+	if re.Data != nil {
+		details["data"] = re.Data
+	}
+	writer.Reset()
+	return writer.Serialize(details)
+}
+
+func readError(reader *Reader, istream *BytesReader) (*RemoteError, error) {
+	reader.Reset()
+	message, err := reader.ReadString()
+	if err != nil {
+		return nil, err
+	}
+	e := &RemoteError{Message: message}
+	if istream.Pos < len(istream.Bytes) && istream.Bytes[istream.Pos] == TagMap {
+		reader.Reset()
+		var details map[string]interface{}
+		if err = reader.Unserialize(&details); err != nil {
+			return nil, err
+		}
+		if code, ok := details["code"].(int); ok {
+			e.Code = code
+		}
+		if stack, ok := details["stack"].(string); ok {
+			e.Stack = stack
+			e.Message = strings.TrimSuffix(message, "\r\n"+stack)
+		}
+		e.Data = details["data"]
+		if v := reflect.ValueOf(e.Data); v.Kind() == reflect.Ptr && !v.IsNil() {
+			e.Data = v.Elem().Interface()
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,125 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/retry_policy.go                                 *
+ *                                                        *
+ * hprose retry policy for Go.                            *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// RetryPolicy retries the idempotent invokings of the client which fail by
+// the retryable errors. The delay before the nth retry is BaseDelay * 2^(n-1)
+// limited by MaxDelay, and the Jitter part of the delay is random.
+type RetryPolicy struct {
+	MaxAttempts int              // the max attempts of an invoking, including the first one
+	BaseDelay   time.Duration    // the delay before the first retry
+	MaxDelay    time.Duration    // the max delay between the attempts, 0 is unlimited
+	Jitter      float64          // the random part of the delay, from 0 to 1
+	Retryable   func(error) bool // classifies the retryable errors, nil is IsRetryable
+}
+
+// NewRetryPolicy is the constructor of RetryPolicy
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		BaseDelay:   100 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+		Jitter:      0.5,
+	}
+}
+
+// IsRetryable returns whether the err is the failure of the transport, such
+// as the network errors, the closed connections and the 5xx http statuses.
+// The errors returned by the service and the done ctx aren't retryable.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var remoteError *RemoteError
+	if errors.As(err, &remoteError) {
+		return false
+	}
+	var statusError *HttpStatusError
+	if errors.As(err, &statusError) {
+		return statusError.StatusCode >= 500 || statusError.StatusCode == 429
+	}
+	var netError net.Error
+	return errors.As(err, &netError) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, errDuplexConnClosed)
+}
+
+func (policy *RetryPolicy) retryable(err error) bool {
+	if policy.Retryable != nil {
+		return policy.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// Delay returns the delay before the retry after the attempt, the delay
+// saturates at math.MaxInt64 when MaxDelay isn't set
+func (policy *RetryPolicy) Delay(attempt int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < attempt && (policy.MaxDelay <= 0 || delay < policy.MaxDelay); i++ {
+		if delay > math.MaxInt64/2 {
+			delay = math.MaxInt64
+			break
+		}
+		delay *= 2
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if policy.Jitter > 0 {
+		jitter := policy.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		delay -= time.Duration(rand.Float64() * jitter * float64(delay))
+	}
+	return delay
+}
+
+// retry calls invoke until it succeeds, the error isn't retryable or the
+// attempts are used up, only the idempotent invokings are retried
+func (client *BaseClient) retry(options *InvokeOptions, context *ClientContext, invoke func() error) error {
+	policy := client.RetryPolicy
+	for attempt := 1; ; attempt++ {
+		context.Attempt = attempt
+		err := invoke()
+		if err == nil || !options.Idempotent || policy == nil ||
+			attempt >= policy.MaxAttempts || !policy.retryable(err) {
+			return err
+		}
+		timer := time.NewTimer(policy.Delay(attempt))
+		select {
+		case <-timer.C:
+		case <-context.Context().Done():
+			timer.Stop()
+			return context.Context().Err()
+		}
+	}
+}
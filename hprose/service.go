@@ -12,7 +12,7 @@
  *                                                        *
  * hprose service for Go.                                 *
  *                                                        *
- * LastModified: Jul 4, 2015                              *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -26,6 +26,7 @@ import (
 	"reflect"
 	"runtime/debug"
 	"strings"
+	"time"
 )
 
 // MissingMethod is missing method
@@ -58,11 +59,16 @@ type sendError2Event interface {
 	OnSendError(err error, context Context) error
 }
 
+// ParamNames is the option of AddFunction which names the parameters
+// for the named params of JSON-RPC, the context parameters are excluded.
+type ParamNames []string
+
 // Method is the publish service method
 type Method struct {
 	Function   reflect.Value
 	ResultMode ResultMode
 	SimpleMode bool
+	ParamNames ParamNames
 }
 
 // NewMethod is the constructor for Method
@@ -91,7 +97,7 @@ func NewMethods() (methods *Methods) {
 // AddFunction publish a func or bound method
 // name is the method name
 // function is a func or bound method
-// options is ResultMode, SimpleMode and prefix
+// options is ResultMode, SimpleMode, prefix and ParamNames
 func (methods *Methods) AddFunction(name string, function interface{}, options ...interface{}) {
 	if name == "" {
 		panic("name can't be empty")
@@ -107,6 +113,7 @@ func (methods *Methods) AddFunction(name string, function interface{}, options .
 	resultMode := Normal
 	simpleMode := false
 	prefix := ""
+	var paramNames ParamNames
 	for i := 0; i < count; i++ {
 		switch opt := options[i].(type) {
 		case ResultMode:
@@ -115,6 +122,8 @@ func (methods *Methods) AddFunction(name string, function interface{}, options .
 			simpleMode = opt
 		case string:
 			prefix = opt
+		case ParamNames:
+			paramNames = opt
 		default:
 			panic("unknown options")
 		}
@@ -122,8 +131,10 @@ func (methods *Methods) AddFunction(name string, function interface{}, options .
 	if prefix != "" && name != "*" {
 		name = prefix + "_" + name
 	}
+	method := NewMethod(f, resultMode, simpleMode)
+	method.ParamNames = paramNames
 	methods.MethodNames = append(methods.MethodNames, name)
-	methods.RemoteMethods[strings.ToLower(name)] = NewMethod(f, resultMode, simpleMode)
+	methods.RemoteMethods[strings.ToLower(name)] = method
 }
 
 // AddFunctions ...
@@ -142,11 +153,19 @@ func (methods *Methods) AddFunctions(names []string, functions []interface{}, op
 
 // AddMethods ...
 // obj is service object. all the public method and func field will be published
-// options is the same as AddFuntion
+// options is the same as AddFuntion, if a nil pointer to the service interface,
+// such as (*Calculator)(nil), is in it, only the methods declared in the
+// interface will be published, the same as AddInterfaceMethods.
 func (methods *Methods) AddMethods(obj interface{}, options ...interface{}) {
 	if obj == nil {
 		panic("obj can't be nil")
 	}
+	for i, opt := range options {
+		if t := reflect.TypeOf(opt); t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Interface {
+			methods.AddInterfaceMethods(opt, obj, append(options[:i:i], options[i+1:]...)...)
+			return
+		}
+	}
 	v := reflect.ValueOf(obj)
 	t := v.Type()
 	n := t.NumMethod()
@@ -263,9 +282,14 @@ func fixArgs(args []reflect.Value, lastParamType reflect.Type, context Context)
 type BaseService struct {
 	*Methods
 	ServiceEvent
-	DebugEnabled bool
-	filters      []Filter
-	argsfixer    ArgsFixer
+	DebugEnabled         bool
+	DescribeEnabled      bool
+	filters              []Filter
+	argsfixer            ArgsFixer
+	codecs               map[string]Codec
+	invokeHandlers       invokeHandlers
+	beforeFilterHandlers filterHandlers
+	afterFilterHandlers  filterHandlers
 }
 
 // NewBaseService is the constructor for BaseService
@@ -312,12 +336,19 @@ func (service *BaseService) RemoveFilter(filter Filter) {
 	}
 }
 
-func (service *BaseService) responseEnd(buf []byte, context Context) []byte {
+func (service *BaseService) inputFilter(data []byte, context Context) []byte {
+	for i := len(service.filters) - 1; i >= 0; i-- {
+		data = service.filters[i].InputFilter(data, context)
+	}
+	return data
+}
+
+func (service *BaseService) outputFilter(data []byte, context Context) []byte {
 	n := len(service.filters)
 	for i := 0; i < n; i++ {
-		buf = service.filters[i].OutputFilter(buf, context)
+		data = service.filters[i].OutputFilter(data, context)
 	}
-	return buf
+	return data
 }
 
 func (service *BaseService) fireErrorEvent(err error, context Context) error {
@@ -337,15 +368,20 @@ func (service *BaseService) fireErrorEvent(err error, context Context) error {
 }
 
 func (service *BaseService) sendError(err error, context Context) []byte {
-	err = service.fireErrorEvent(err, context)
 	buf := new(bytes.Buffer)
-	writer := NewWriter(buf, true)
-	writer.Stream.WriteByte(TagError)
-	writer.WriteString(err.Error())
-	writer.Stream.WriteByte(TagEnd)
-	return service.responseEnd(buf.Bytes(), context)
+	service.writeError(buf, err, context)
+	buf.WriteByte(TagEnd)
+	return buf.Bytes()
+}
+
+func (service *BaseService) writeError(buf *bytes.Buffer, err error, context Context) {
+	err = service.fireErrorEvent(err, context)
+	writeError(NewWriter(buf, true), err)
 }
 
+// doInvoke handles every call in the request independently, so that one
+// failed call writes its own TagError and doesn't fail the others. Only the
+// malformed request, which can't be split into calls, fails as a whole.
 func (service *BaseService) doInvoke(data []byte, context Context) []byte {
 	istream := NewBytesReader(data)
 	reader := NewReader(istream, false)
@@ -356,69 +392,18 @@ func (service *BaseService) doInvoke(data []byte, context Context) []byte {
 		if err != nil {
 			return service.sendError(err, context)
 		}
-		alias := strings.ToLower(name)
-		remoteMethod := service.RemoteMethods[alias]
-		count := 0
-		var args []reflect.Value
+		var args []byte
 		byref := false
 		var tag byte
 		if tag, err = reader.CheckTags([]byte{TagList, TagEnd, TagCall}); err != nil {
 			return service.sendError(err, context)
 		}
 		if tag == TagList {
-			reader.Reset()
-			if count, err = reader.ReadInteger(TagOpenbrace); err != nil {
+			ostream := new(bytes.Buffer)
+			if err = reader.readRaw(ostream, TagList); err != nil {
 				return service.sendError(err, context)
 			}
-			args = make([]reflect.Value, count)
-			if remoteMethod == nil {
-				for i := 0; i < count; i++ {
-					var e interface{}
-					args[i] = reflect.ValueOf(&e).Elem()
-				}
-				if err = reader.ReadArray(args); err != nil {
-					return service.sendError(err, context)
-				}
-			} else {
-				ft := remoteMethod.Function.Type()
-				n := ft.NumIn()
-				if ft.IsVariadic() {
-					n--
-				}
-				if n < count {
-					for i := 0; i < n; i++ {
-						args[i] = reflect.New(ft.In(i)).Elem()
-					}
-					if ft.IsVariadic() {
-						t := ft.In(n).Elem()
-						for i := n; i < count; i++ {
-							args[i] = reflect.New(t).Elem()
-						}
-						if err = reader.ReadArray(args); err != nil {
-							return service.sendError(err, context)
-						}
-					} else {
-						for i := n; i < count; i++ {
-							var e interface{}
-							args[i] = reflect.ValueOf(&e).Elem()
-						}
-						if err = reader.ReadArray(args); err != nil {
-							return service.sendError(err, context)
-						}
-						args = args[:n]
-					}
-				} else {
-					for i := 0; i < count; i++ {
-						args[i] = reflect.New(ft.In(i)).Elem()
-					}
-					if err = reader.ReadArray(args[0:count]); err != nil {
-						return service.sendError(err, context)
-					}
-					if count+1 == n {
-						args = service.argsfixer.FixArgs(args, ft.In(count), context)
-					}
-				}
-			}
+			args = ostream.Bytes()
 			if tag, err = reader.CheckTags([]byte{TagTrue, TagEnd, TagCall}); err != nil {
 				return service.sendError(err, context)
 			}
@@ -428,131 +413,278 @@ func (service *BaseService) doInvoke(data []byte, context Context) []byte {
 					return service.sendError(err, context)
 				}
 			}
+		}
+		output, mode, err := service.invoke(name, args, byref, context)
+		if err != nil {
+			service.writeError(buf, err, context)
+		} else if mode == RawWithEndTag {
+			return output
 		} else {
-			args = make([]reflect.Value, 0)
-			if remoteMethod != nil {
-				ft := remoteMethod.Function.Type()
-				if ft.NumIn() == 1 && !ft.IsVariadic() {
-					args = service.argsfixer.FixArgs(args, ft.In(0), context)
-				}
-			}
+			buf.Write(output)
 		}
-		if service.ServiceEvent != nil {
-			if event, ok := service.ServiceEvent.(beforeInvokeEvent); ok {
-				event.OnBeforeInvoke(name, args, byref, context)
-			} else if event, ok := service.ServiceEvent.(beforeInvoke2Event); ok {
-				err = event.OnBeforeInvoke(name, args, byref, context)
-				if err != nil {
-					return service.sendError(err, context)
-				}
-			}
+		if tag != TagCall {
+			break
 		}
-		var result []reflect.Value
-		if result, err = func() (out []reflect.Value, err error) {
-			defer func() {
-				if e := recover(); e != nil && err == nil {
-					if service.DebugEnabled {
-						err = fmt.Errorf("%v\r\n%s", e, debug.Stack())
-					} else {
-						err = fmt.Errorf("%v", e)
-					}
-				}
-			}()
-			if remoteMethod == nil {
-				remoteMethod = service.RemoteMethods["*"]
-				if remoteMethod == nil {
-					return nil, errors.New("Can't find this method " + name)
-				}
-				if missingMethod, ok := remoteMethod.Function.Interface().(MissingMethod); ok {
-					return missingMethod(name, args), nil
-				}
-				return nil, errors.New("Can't find this method " + name)
+	}
+	buf.WriteByte(TagEnd)
+	return buf.Bytes()
+}
+
+func (service *BaseService) readArgs(remoteMethod *Method, data []byte, context Context) (args []reflect.Value, err error) {
+	if remoteMethod == nil {
+		return service.readArgsOfType(nil, 0, data, context)
+	}
+	ft := remoteMethod.Function.Type()
+	if hasContextParam(ft) {
+		return service.readArgsOfType(ft, 1, data, context)
+	}
+	return service.readArgsOfType(ft, 0, data, context)
+}
+
+func hasContextParam(ft reflect.Type) bool {
+	return ft.NumIn() > 0 && ft.In(0) == contextType
+}
+
+// readArgsOfType reads the arguments for the parameters of ft from offset,
+// the context.Context first parameter is skipped by the offset.
+func (service *BaseService) readArgsOfType(ft reflect.Type, offset int, data []byte, context Context) (args []reflect.Value, err error) {
+	if data == nil {
+		args = make([]reflect.Value, 0)
+		if ft != nil {
+			if ft.NumIn()-offset == 1 && !ft.IsVariadic() {
+				args = service.argsfixer.FixArgs(args, ft.In(offset), context)
 			}
-			return remoteMethod.Function.Call(args), nil
-		}(); err != nil {
-			return service.sendError(err, context)
 		}
-		if service.ServiceEvent != nil {
-			if event, ok := service.ServiceEvent.(afterInvokeEvent); ok {
-				event.OnAfterInvoke(name, args, byref, result, context)
-			} else if event, ok := service.ServiceEvent.(afterInvoke2Event); ok {
-				err = event.OnAfterInvoke(name, args, byref, result, context)
-				if err != nil {
-					return service.sendError(err, context)
-				}
-			}
+		return args, nil
+	}
+	reader := NewReader(NewBytesReader(data), false)
+	if err = reader.CheckTag(TagList); err != nil {
+		return nil, err
+	}
+	var count int
+	if count, err = reader.ReadInteger(TagOpenbrace); err != nil {
+		return nil, err
+	}
+	args = make([]reflect.Value, count)
+	if ft == nil {
+		for i := 0; i < count; i++ {
+			var e interface{}
+			args[i] = reflect.ValueOf(&e).Elem()
 		}
-		resultLength := len(result)
-		if resultLength > 0 {
-			t := remoteMethod.Function.Type().Out(resultLength - 1)
-			if t.Implements(reflect.TypeOf(&err).Elem()) {
-				if err, ok := result[resultLength-1].Interface().(error); ok {
-					return service.sendError(err, context)
-				}
-				resultLength--
-				result = result[:resultLength]
-			}
+		return args, reader.ReadArray(args)
+	}
+	n := ft.NumIn() - offset
+	if ft.IsVariadic() {
+		n--
+	}
+	if n < count {
+		for i := 0; i < n; i++ {
+			args[i] = reflect.New(ft.In(i + offset)).Elem()
 		}
-		if remoteMethod.ResultMode != Normal {
-			if resultLength == 0 {
-				return service.sendError(errors.New("can't find the result value"), context)
+		if ft.IsVariadic() {
+			t := ft.In(n + offset).Elem()
+			for i := n; i < count; i++ {
+				args[i] = reflect.New(t).Elem()
 			}
-			switch r := result[0].Interface().(type) {
-			case []byte:
-				data = r
-			case *[]byte:
-				data = *r
-			case bytes.Buffer:
-				data = r.Bytes()
-			case *bytes.Buffer:
-				data = r.Bytes()
-			case string:
-				data = []byte(r)
-			case *string:
-				data = []byte(*r)
-			default:
-				return service.sendError(errors.New("the result type is wrong"), context)
-			}
-			if remoteMethod.ResultMode == RawWithEndTag {
-				return service.responseEnd(data, context)
+			return args, reader.ReadArray(args)
+		}
+		for i := n; i < count; i++ {
+			var e interface{}
+			args[i] = reflect.ValueOf(&e).Elem()
+		}
+		if err = reader.ReadArray(args); err != nil {
+			return nil, err
+		}
+		return args[:n], nil
+	}
+	for i := 0; i < count; i++ {
+		args[i] = reflect.New(ft.In(i + offset)).Elem()
+	}
+	if err = reader.ReadArray(args[0:count]); err != nil {
+		return nil, err
+	}
+	if count+1 == n {
+		args = service.argsfixer.FixArgs(args, ft.In(count+offset), context)
+	}
+	return args, nil
+}
+
+// getMethod returns the published method of name, or nil
+func (service *BaseService) getMethod(name string) *Method {
+	remoteMethod := service.RemoteMethods[strings.ToLower(name)]
+	if remoteMethod == nil && service.DescribeEnabled && name == DescribeMethodName {
+		remoteMethod = NewMethod(reflect.ValueOf(service.Describe), Normal, false)
+	}
+	return remoteMethod
+}
+
+func (service *BaseService) recoverError(e interface{}) error {
+	if service.DebugEnabled {
+		return &RemoteError{Message: fmt.Sprint(e), Stack: string(debug.Stack())}
+	}
+	return fmt.Errorf("%v", e)
+}
+
+// Use adds the invoke handlers, the first added handler is called first
+func (service *BaseService) Use(handler ...InvokeHandler) {
+	service.invokeHandlers = append(service.invokeHandlers, handler...)
+}
+
+// AddBeforeFilterHandler adds the handlers which get the request before the
+// input filters and the response after the output filters
+func (service *BaseService) AddBeforeFilterHandler(handler ...FilterHandler) {
+	service.beforeFilterHandlers = append(service.beforeFilterHandlers, handler...)
+}
+
+// AddAfterFilterHandler adds the handlers which get the request after the
+// input filters and the response before the output filters
+func (service *BaseService) AddAfterFilterHandler(handler ...FilterHandler) {
+	service.afterFilterHandlers = append(service.afterFilterHandlers, handler...)
+}
+
+// missingMethod returns the method added by AddMissingMethod, or nil
+func (service *BaseService) missingMethod() *Method {
+	remoteMethod := service.RemoteMethods["*"]
+	if remoteMethod == nil {
+		return nil
+	}
+	if _, ok := remoteMethod.Function.Interface().(MissingMethod); !ok {
+		return nil
+	}
+	return remoteMethod
+}
+
+// call calls the method by the invoke handlers, it returns the method which
+// is called actually and the results without the error result.
+func (service *BaseService) call(name string, remoteMethod *Method, args []reflect.Value, byref bool, context Context) (*Method, []reflect.Value, error) {
+	if remoteMethod == nil {
+		if remoteMethod = service.missingMethod(); remoteMethod == nil {
+			return nil, nil, errors.New("Can't find this method " + name)
+		}
+	}
+	handler := service.invokeHandlers.handler(func(name string, args []reflect.Value, context Context) ([]reflect.Value, error) {
+		return service.execute(name, remoteMethod, args, byref, context)
+	})
+	result, err := handler(name, args, context)
+	if err != nil {
+		return nil, nil, err
+	}
+	return remoteMethod, result, nil
+}
+
+// execute calls the method with the invoke events
+func (service *BaseService) execute(name string, remoteMethod *Method, args []reflect.Value, byref bool, context Context) ([]reflect.Value, error) {
+	if service.ServiceEvent != nil {
+		if event, ok := service.ServiceEvent.(beforeInvokeEvent); ok {
+			event.OnBeforeInvoke(name, args, byref, context)
+		} else if event, ok := service.ServiceEvent.(beforeInvoke2Event); ok {
+			if err := event.OnBeforeInvoke(name, args, byref, context); err != nil {
+				return nil, err
 			}
 		}
-		if remoteMethod.ResultMode == Raw {
-			buf.Write(data)
-		} else {
-			writer := NewWriter(buf, remoteMethod.SimpleMode)
-			writer.Stream.WriteByte(TagResult)
-			if remoteMethod.ResultMode == Serialized {
-				if _, err = writer.Stream.Write(data); err != nil {
-					return service.sendError(err, context)
-				}
-			} else {
-				switch resultLength {
-				case 0:
-					err = writer.Serialize(nil)
-				case 1:
-					err = writer.WriteValue(result[0])
-				default:
-					err = writer.WriteArray(result)
-				}
-				if err != nil {
-					return service.sendError(err, context)
-				}
+	}
+	if err := getContext(context).Err(); err != nil {
+		return nil, err
+	}
+	var result []reflect.Value
+	if missingMethod, ok := remoteMethod.Function.Interface().(MissingMethod); ok && remoteMethod == service.RemoteMethods["*"] {
+		result = missingMethod(name, args)
+	} else if hasContextParam(remoteMethod.Function.Type()) {
+		in := append([]reflect.Value{reflect.ValueOf(getContext(context))}, args...)
+		result = remoteMethod.Function.Call(in)
+	} else {
+		result = remoteMethod.Function.Call(args)
+	}
+	if service.ServiceEvent != nil {
+		if event, ok := service.ServiceEvent.(afterInvokeEvent); ok {
+			event.OnAfterInvoke(name, args, byref, result, context)
+		} else if event, ok := service.ServiceEvent.(afterInvoke2Event); ok {
+			if err := event.OnAfterInvoke(name, args, byref, result, context); err != nil {
+				return nil, err
 			}
-			if byref {
-				writer.Stream.WriteByte(TagArgument)
-				writer.Reset()
-				if err = writer.WriteArray(args); err != nil {
-					return service.sendError(err, context)
-				}
+		}
+	}
+	if n := len(result); n > 0 {
+		t := remoteMethod.Function.Type().Out(n - 1)
+		if t.Implements(errorType) {
+			if err, ok := result[n-1].Interface().(error); ok {
+				return nil, err
 			}
+			result = result[:n-1]
 		}
-		if tag != TagCall {
-			break
+	}
+	return result, nil
+}
+
+func (service *BaseService) invoke(name string, data []byte, byref bool, context Context) (output []byte, mode ResultMode, err error) {
+	defer func() {
+		if e := recover(); e != nil && err == nil {
+			err = service.recoverError(e)
 		}
+	}()
+	remoteMethod := service.getMethod(name)
+	var args []reflect.Value
+	if args, err = service.readArgs(remoteMethod, data, context); err != nil {
+		return nil, Normal, err
 	}
-	buf.WriteByte(TagEnd)
-	return service.responseEnd(buf.Bytes(), context)
+	var result []reflect.Value
+	if remoteMethod, result, err = service.call(name, remoteMethod, args, byref, context); err != nil {
+		return nil, Normal, err
+	}
+	mode = remoteMethod.ResultMode
+	resultLength := len(result)
+	if mode != Normal {
+		if resultLength == 0 {
+			return nil, mode, errors.New("can't find the result value")
+		}
+		switch r := result[0].Interface().(type) {
+		case []byte:
+			output = r
+		case *[]byte:
+			output = *r
+		case bytes.Buffer:
+			output = r.Bytes()
+		case *bytes.Buffer:
+			output = r.Bytes()
+		case string:
+			output = []byte(r)
+		case *string:
+			output = []byte(*r)
+		default:
+			return nil, mode, errors.New("the result type is wrong")
+		}
+		if mode == Raw || mode == RawWithEndTag {
+			return output, mode, nil
+		}
+	}
+	buf := new(bytes.Buffer)
+	writer := NewWriter(buf, remoteMethod.SimpleMode)
+	writer.Stream.WriteByte(TagResult)
+	if mode == Serialized {
+		if _, err = writer.Stream.Write(output); err != nil {
+			return nil, mode, err
+		}
+	} else {
+		switch resultLength {
+		case 0:
+			err = writer.Serialize(nil)
+		case 1:
+			err = writer.WriteValue(result[0])
+		default:
+			err = writer.WriteArray(result)
+		}
+		if err != nil {
+			return nil, mode, err
+		}
+	}
+	if byref {
+		writer.Stream.WriteByte(TagArgument)
+		writer.Reset()
+		if err = writer.WriteArray(args); err != nil {
+			return nil, mode, err
+		}
+	}
+	return buf.Bytes(), mode, nil
 }
 
 func (service *BaseService) doFunctionList(context Context) []byte {
@@ -563,35 +695,89 @@ func (service *BaseService) doFunctionList(context Context) []byte {
 		return service.sendError(err, context)
 	}
 	writer.Stream.WriteByte(TagEnd)
-	return service.responseEnd(buf.Bytes(), context)
+	return buf.Bytes()
 }
 
 // Handle the hprose request and return the hprose response
 func (service *BaseService) Handle(data []byte, context Context) (output []byte) {
 	defer func() {
 		if e := recover(); e != nil {
-			var err error
-			if service.DebugEnabled {
-				err = fmt.Errorf("%v\r\n%s", e, debug.Stack())
-			} else {
-				err = fmt.Errorf("%v", e)
-			}
-			output = service.sendError(err, context)
+			output = service.outputFilter(service.errorResponse(service.recoverError(e), context), context)
 		}
 	}()
-	for i := len(service.filters) - 1; i >= 0; i-- {
-		data = service.filters[i].InputFilter(data, context)
+	context.SetInterface(serviceContextKey, service)
+	output, err := service.beforeFilterHandlers.handler(service.filter)(data, context)
+	if err != nil {
+		return service.outputFilter(service.errorResponse(err, context), context)
+	}
+	return output
+}
+
+// filter handles the request by the filters and the after filter handlers
+func (service *BaseService) filter(data []byte, context Context) ([]byte, error) {
+	data = service.inputFilter(data, context)
+	output, err := service.afterFilterHandlers.handler(service.handle)(data, context)
+	if err != nil {
+		return nil, err
+	}
+	return service.outputFilter(output, context), nil
+}
+
+func (service *BaseService) handle(data []byte, context Context) ([]byte, error) {
+	if codec := getCodec(context); codec != nil {
+		return service.handleCodec(codec, data, context), nil
 	}
 	if len(data) == 0 {
-		return service.sendError(errors.New("no Hprose RPC request"), context)
+		return nil, errors.New("no Hprose RPC request")
 	}
 	tag := data[0]
+	if tag == TagHeader {
+		headers, rest, err := readHeaders(data[1:])
+		if err != nil {
+			return nil, err
+		}
+		if timeout, ok := headers[timeoutHeader].(int); ok {
+			cancel := setContextTimeout(context, time.Duration(timeout)*time.Millisecond)
+			defer cancel()
+		}
+		if len(rest) == 0 {
+			return nil, errors.New("no Hprose RPC request")
+		}
+		data = rest
+		tag = data[0]
+	}
 	switch tag {
 	case TagCall:
-		return service.doInvoke(data[1:], context)
+		return service.doInvoke(data[1:], context), nil
 	case TagEnd:
-		return service.doFunctionList(context)
+		return service.doFunctionList(context), nil
 	default:
-		return service.sendError(errors.New("Wrong Reqeust: \r\n"+string(data)), context)
+		return nil, errors.New("Wrong Reqeust: \r\n" + string(data))
+	}
+}
+
+// errorResponse returns the response of the error in the format of the
+// request
+func (service *BaseService) errorResponse(err error, context Context) []byte {
+	if codec := getCodec(context); codec != nil {
+		return service.sendCodecError(codec, err, context)
+	}
+	return service.sendError(err, context)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// serviceContextKey is the key of the service in the context, which is
+// used by the service filters
+const serviceContextKey = "hprose.service"
+
+func readHeaders(data []byte) (headers map[string]interface{}, rest []byte, err error) {
+	var raw []byte
+	if raw, err = NewRawReader(NewBytesReader(data)).ReadRaw(); err != nil {
+		return nil, nil, err
+	}
+	if err = Unserialize(raw, &headers, true); err != nil {
+		return nil, nil, err
 	}
+	return headers, data[len(raw):], nil
 }
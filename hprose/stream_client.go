@@ -12,7 +12,7 @@
  *                                                        *
  * hprose stream client for Go.                           *
  *                                                        *
- * LastModified: May 27, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Authors: Ma Bingyao <andot@hprose.com>                 *
  *          Ore_Ash <nanohugh@gmail.com>                  *
  *                                                        *
@@ -21,8 +21,12 @@
 package hprose
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -34,6 +38,9 @@ type StreamClient struct {
 	readTimeout  interface{}
 	writeBuffer  interface{}
 	writeTimeout interface{}
+	fullDuplex   int32 // accessed atomically
+	duplex       *duplexConn
+	duplexMutex  sync.Mutex
 }
 
 func newStreamClient(trans Transporter) (client *StreamClient) {
@@ -235,3 +242,202 @@ func (client *StreamClient) SetWriteBuffer(bytes int) {
 func (client *StreamClient) SetWriteTimeout(d time.Duration) {
 	client.writeTimeout = d
 }
+
+func (client *StreamClient) exchange(ctx context.Context, conn net.Conn, odata []byte) (idata []byte, err error) {
+	done := ctx.Done()
+	if done == nil {
+		return client.sendAndReceiveOverStream(conn, odata)
+	}
+	aborted := false
+	stop := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-done:
+			aborted = true
+			conn.SetDeadline(time.Now())
+		case <-stop:
+		}
+	}()
+	idata, err = client.sendAndReceiveOverStream(conn, odata)
+	close(stop)
+	<-exited
+	if aborted {
+		// the deadline of conn has been changed, so it can't be reused.
+		return nil, ctx.Err()
+	}
+	return idata, err
+}
+
+func (client *StreamClient) sendAndReceiveOverStream(conn net.Conn, odata []byte) (idata []byte, err error) {
+	if client.writeTimeout != nil {
+		if err = conn.SetWriteDeadline(time.Now().Add(client.writeTimeout.(time.Duration))); err != nil {
+			return nil, err
+		}
+	}
+	if err = sendDataOverStream(conn, odata); err != nil {
+		return nil, err
+	}
+	if client.readTimeout != nil {
+		if err = conn.SetReadDeadline(time.Now().Add(client.readTimeout.(time.Duration))); err != nil {
+			return nil, err
+		}
+	}
+	return receiveDataOverStream(conn)
+}
+
+// FullDuplex returns whether the requests are sent over one connection
+func (client *StreamClient) FullDuplex() bool {
+	return atomic.LoadInt32(&client.fullDuplex) != 0
+}
+
+// SetFullDuplex sets whether the requests are sent over one connection
+// with request ids, so that the responses can be returned out of order.
+//
+// The service must be hprose for Golang service which supports the full
+// duplex mode. The readTimeout is used as the timeout of each request in
+// this mode.
+func (client *StreamClient) SetFullDuplex(fullDuplex bool) {
+	if fullDuplex {
+		atomic.StoreInt32(&client.fullDuplex, 1)
+	} else {
+		atomic.StoreInt32(&client.fullDuplex, 0)
+		client.closeDuplex()
+	}
+}
+
+var errDuplexConnClosed = errors.New("The full duplex connection has been closed.")
+
+type duplexConn struct {
+	conn       net.Conn
+	id         uint32
+	results    map[uint32]chan recvMessage
+	mutex      sync.Mutex
+	writeMutex sync.Mutex
+}
+
+func (d *duplexConn) register() (id uint32, recv chan recvMessage, err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.results == nil {
+		return 0, nil, errDuplexConnClosed
+	}
+	d.id++
+	recv = make(chan recvMessage, 1)
+	d.results[d.id] = recv
+	return d.id, recv, nil
+}
+
+func (d *duplexConn) unregister(id uint32) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.results != nil {
+		delete(d.results, id)
+	}
+}
+
+func (d *duplexConn) dispatch(id uint32, data []byte) {
+	d.mutex.Lock()
+	recv, ok := d.results[id]
+	delete(d.results, id)
+	d.mutex.Unlock()
+	if ok {
+		recv <- recvMessage{data, nil}
+	}
+}
+
+func (d *duplexConn) close(err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.results == nil {
+		return
+	}
+	d.conn.Close()
+	for _, recv := range d.results {
+		recv <- recvMessage{nil, err}
+	}
+	d.results = nil
+}
+
+func (client *StreamClient) getDuplexConn(ctx context.Context, uri string, dial func(context.Context, string) (net.Conn, error)) (*duplexConn, error) {
+	client.duplexMutex.Lock()
+	defer client.duplexMutex.Unlock()
+	if client.duplex != nil {
+		return client.duplex, nil
+	}
+	conn, err := dial(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	d := &duplexConn{conn: conn, results: make(map[uint32]chan recvMessage)}
+	client.duplex = d
+	go client.duplexRecvLoop(d)
+	return d, nil
+}
+
+func (client *StreamClient) removeDuplexConn(d *duplexConn, err error) {
+	client.duplexMutex.Lock()
+	if client.duplex == d {
+		client.duplex = nil
+	}
+	client.duplexMutex.Unlock()
+	d.close(err)
+}
+
+func (client *StreamClient) closeDuplex() {
+	client.duplexMutex.Lock()
+	d := client.duplex
+	client.duplex = nil
+	client.duplexMutex.Unlock()
+	if d != nil {
+		d.close(errDuplexConnClosed)
+	}
+}
+
+func (client *StreamClient) duplexRecvLoop(d *duplexConn) {
+	reader := bufio.NewReader(d.conn)
+	for {
+		id, data, _, err := receiveFrameOverStream(reader)
+		if err != nil {
+			client.removeDuplexConn(d, err)
+			return
+		}
+		d.dispatch(id, data)
+	}
+}
+
+func (client *StreamClient) duplexSendAndReceive(ctx context.Context, uri string, odata []byte, dial func(context.Context, string) (net.Conn, error)) ([]byte, error) {
+	d, err := client.getDuplexConn(ctx, uri, dial)
+	if err != nil {
+		return nil, err
+	}
+	if client.readTimeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, client.readTimeout.(time.Duration))
+		defer cancel()
+	}
+	id, recv, err := d.register()
+	if err != nil {
+		return nil, err
+	}
+	d.writeMutex.Lock()
+	if client.writeTimeout != nil {
+		err = d.conn.SetWriteDeadline(time.Now().Add(client.writeTimeout.(time.Duration)))
+	}
+	if err == nil {
+		err = sendFrameOverStream(d.conn, id, odata)
+	}
+	d.writeMutex.Unlock()
+	if err != nil {
+		client.removeDuplexConn(d, err)
+		return nil, err
+	}
+	select {
+	case result := <-recv:
+		return result.data, result.err
+	case <-ctx.Done():
+		d.unregister(id)
+		return nil, ctx.Err()
+	}
+}
@@ -12,7 +12,7 @@
  *                                                        *
  * hprose stream common for Go.                           *
  *                                                        *
- * LastModified: Jan 28, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Authors: Ma Bingyao <andot@hprose.com>                 *
  *          Ore_Ash <nanohugh@gmail.com>                  *
  *                                                        *
@@ -74,3 +74,43 @@ func receiveDataOverStream(r io.Reader) ([]byte, error) {
 	_, err = io.ReadAtLeast(r, data[n-4:], size)
 	return data, err
 }
+
+// sendFrameOverStream sends the data with the request id in full duplex mode,
+// the highest bit of the length is set to mark the frame carries an id.
+func sendFrameOverStream(w io.Writer, id uint32, data []byte) (err error) {
+	n := len(data)
+	buf := make([]byte, n+8)
+	buf[0] = byte((n>>24)&0x7f | 0x80)
+	buf[1] = byte((n >> 16) & 0xff)
+	buf[2] = byte((n >> 8) & 0xff)
+	buf[3] = byte(n & 0xff)
+	buf[4] = byte((id >> 24) & 0xff)
+	buf[5] = byte((id >> 16) & 0xff)
+	buf[6] = byte((id >> 8) & 0xff)
+	buf[7] = byte(id & 0xff)
+	copy(buf[8:], data)
+	_, err = w.Write(buf)
+	return err
+}
+
+// receiveFrameOverStream receives the data in either half or full duplex mode,
+// duplex reports whether the frame carries a request id.
+func receiveFrameOverStream(r io.Reader) (id uint32, data []byte, duplex bool, err error) {
+	var buf [8]byte
+	if _, err = io.ReadFull(r, buf[:4]); err != nil {
+		return 0, nil, false, err
+	}
+	length := (int(buf[0]&0x7f)<<24 | int(buf[1])<<16 | int(buf[2])<<8 | int(buf[3]))
+	if duplex = buf[0]&0x80 != 0; duplex {
+		if _, err = io.ReadFull(r, buf[4:]); err != nil {
+			return 0, nil, false, err
+		}
+		id = (uint32(buf[4])<<24 |
+			uint32(buf[5])<<16 |
+			uint32(buf[6])<<8 |
+			uint32(buf[7]))
+	}
+	data = make([]byte, length)
+	_, err = io.ReadFull(r, data)
+	return id, data, duplex, err
+}
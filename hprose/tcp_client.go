@@ -12,7 +12,7 @@
  *                                                        *
  * hprose tcp client for Go.                              *
  *                                                        *
- * LastModified: May 27, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Authors: Ma Bingyao <andot@hprose.com>                 *
  *          Ore_Ash <nanohugh@gmail.com>                  *
  *                                                        *
@@ -21,6 +21,7 @@
 package hprose
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"net/url"
@@ -82,6 +83,7 @@ func (client *TcpClient) Close() {
 	if uri != "" {
 		client.Transporter.(*tcpTransporter).ConnPool.Close(uri)
 	}
+	client.closeDuplex()
 }
 
 // Timeout return the timeout of the connection in client pool
@@ -131,8 +133,68 @@ func (client *TcpClient) SetTLSClientConfig(config *tls.Config) {
 	client.tlsConfig = config
 }
 
+func (t *tcpTransporter) dial(ctx context.Context, uri string) (conn net.Conn, err error) {
+	var u *url.URL
+	if u, err = url.Parse(uri); err != nil {
+		return nil, err
+	}
+	var tcpaddr *net.TCPAddr
+	if tcpaddr, err = net.ResolveTCPAddr(u.Scheme, u.Host); err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	if conn, err = dialer.DialContext(ctx, "tcp", tcpaddr.String()); err != nil {
+		return nil, err
+	}
+	if t.keepAlive != nil {
+		if err = conn.(*net.TCPConn).SetKeepAlive(t.keepAlive.(bool)); err != nil {
+			return nil, err
+		}
+	}
+	if t.keepAlivePeriod != nil {
+		if kap, ok := conn.(iKeepAlivePeriod); ok {
+			if err = kap.SetKeepAlivePeriod(t.keepAlivePeriod.(time.Duration)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if t.linger != nil {
+		if err = conn.(*net.TCPConn).SetLinger(t.linger.(int)); err != nil {
+			return nil, err
+		}
+	}
+	if t.noDelay != nil {
+		if err = conn.(*net.TCPConn).SetNoDelay(t.noDelay.(bool)); err != nil {
+			return nil, err
+		}
+	}
+	if t.readBuffer != nil {
+		if err = conn.(*net.TCPConn).SetReadBuffer(t.readBuffer.(int)); err != nil {
+			return nil, err
+		}
+	}
+	if t.writeBuffer != nil {
+		if err = conn.(*net.TCPConn).SetWriteBuffer(t.writeBuffer.(int)); err != nil {
+			return nil, err
+		}
+	}
+	if t.tlsConfig != nil {
+		conn = tls.Client(conn, t.tlsConfig)
+	}
+	return conn, nil
+}
+
 // SendAndReceive send and receive the data
-func (t *tcpTransporter) SendAndReceive(uri string, odata []byte) (idata []byte, err error) {
+func (t *tcpTransporter) SendAndReceive(uri string, odata []byte) ([]byte, error) {
+	return t.SendAndReceiveContext(backgroundContext, uri, odata)
+}
+
+// SendAndReceiveContext send and receive the data,
+// the connection is closed instead of reused when the ctx is done
+func (t *tcpTransporter) SendAndReceiveContext(ctx context.Context, uri string, odata []byte) (idata []byte, err error) {
+	if t.FullDuplex() {
+		return t.duplexSendAndReceive(ctx, uri, odata, t.dial)
+	}
 	connEntry := t.ConnPool.Get(uri)
 	defer func() {
 		if err != nil {
@@ -143,52 +205,9 @@ func (t *tcpTransporter) SendAndReceive(uri string, odata []byte) (idata []byte,
 begin:
 	conn := connEntry.Get()
 	if conn == nil {
-		var u *url.URL
-		if u, err = url.Parse(uri); err != nil {
+		if conn, err = t.dial(ctx, uri); err != nil {
 			return nil, err
 		}
-		var tcpaddr *net.TCPAddr
-		if tcpaddr, err = net.ResolveTCPAddr(u.Scheme, u.Host); err != nil {
-			return nil, err
-		}
-		if conn, err = net.DialTCP("tcp", nil, tcpaddr); err != nil {
-			return nil, err
-		}
-		if t.keepAlive != nil {
-			if err = conn.(*net.TCPConn).SetKeepAlive(t.keepAlive.(bool)); err != nil {
-				return nil, err
-			}
-		}
-		if t.keepAlivePeriod != nil {
-			if kap, ok := conn.(iKeepAlivePeriod); ok {
-				if err = kap.SetKeepAlivePeriod(t.keepAlivePeriod.(time.Duration)); err != nil {
-					return nil, err
-				}
-			}
-		}
-		if t.linger != nil {
-			if err = conn.(*net.TCPConn).SetLinger(t.linger.(int)); err != nil {
-				return nil, err
-			}
-		}
-		if t.noDelay != nil {
-			if err = conn.(*net.TCPConn).SetNoDelay(t.noDelay.(bool)); err != nil {
-				return nil, err
-			}
-		}
-		if t.readBuffer != nil {
-			if err = conn.(*net.TCPConn).SetReadBuffer(t.readBuffer.(int)); err != nil {
-				return nil, err
-			}
-		}
-		if t.writeBuffer != nil {
-			if err = conn.(*net.TCPConn).SetWriteBuffer(t.writeBuffer.(int)); err != nil {
-				return nil, err
-			}
-		}
-		if t.tlsConfig != nil {
-			conn = tls.Client(conn, t.tlsConfig)
-		}
 		connEntry.Set(conn)
 	}
 	if t.timeout != nil {
@@ -200,20 +219,7 @@ begin:
 			goto begin
 		}
 	}
-	if t.writeTimeout != nil {
-		if err = conn.SetWriteDeadline(time.Now().Add(t.writeTimeout.(time.Duration))); err != nil {
-			return nil, err
-		}
-	}
-	if err = sendDataOverStream(conn, odata); err != nil {
-		return nil, err
-	}
-	if t.readTimeout != nil {
-		if err = conn.SetReadDeadline(time.Now().Add(t.readTimeout.(time.Duration))); err != nil {
-			return nil, err
-		}
-	}
-	if idata, err = receiveDataOverStream(conn); err != nil {
+	if idata, err = t.exchange(ctx, conn, odata); err != nil {
 		return nil, err
 	}
 	t.ConnPool.Free(connEntry)
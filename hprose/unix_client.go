@@ -12,7 +12,7 @@
  *                                                        *
  * hprose unix client for Go.                             *
  *                                                        *
- * LastModified: May 28, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Authors: Ma Bingyao <andot@hprose.com>                 *
  *          Ore_Ash <nanohugh@gmail.com>                  *
  *                                                        *
@@ -21,6 +21,7 @@
 package hprose
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"strings"
@@ -82,6 +83,7 @@ func (client *UnixClient) Close() {
 	if uri != "" {
 		client.Transporter.(*unixTransporter).ConnPool.Close(uri)
 	}
+	client.closeDuplex()
 }
 
 // SetKeepAlive do nothing on unix client
@@ -109,8 +111,43 @@ func (client *UnixClient) SetTLSClientConfig(config *tls.Config) {
 	client.tlsConfig = config
 }
 
+func (t *unixTransporter) dial(ctx context.Context, uri string) (conn net.Conn, err error) {
+	scheme, path := parseUnixUri(uri)
+	var unixaddr *net.UnixAddr
+	if unixaddr, err = net.ResolveUnixAddr(scheme, path); err != nil {
+		return nil, err
+	}
+	var dialer net.Dialer
+	if conn, err = dialer.DialContext(ctx, scheme, unixaddr.String()); err != nil {
+		return nil, err
+	}
+	if t.readBuffer != nil {
+		if err = conn.(*net.UnixConn).SetReadBuffer(t.readBuffer.(int)); err != nil {
+			return nil, err
+		}
+	}
+	if t.writeBuffer != nil {
+		if err = conn.(*net.UnixConn).SetWriteBuffer(t.writeBuffer.(int)); err != nil {
+			return nil, err
+		}
+	}
+	if t.tlsConfig != nil {
+		conn = tls.Client(conn, t.tlsConfig)
+	}
+	return conn, nil
+}
+
 // SendAndReceive send and receive the data
-func (t *unixTransporter) SendAndReceive(uri string, odata []byte) (idata []byte, err error) {
+func (t *unixTransporter) SendAndReceive(uri string, odata []byte) ([]byte, error) {
+	return t.SendAndReceiveContext(backgroundContext, uri, odata)
+}
+
+// SendAndReceiveContext send and receive the data,
+// the connection is closed instead of reused when the ctx is done
+func (t *unixTransporter) SendAndReceiveContext(ctx context.Context, uri string, odata []byte) (idata []byte, err error) {
+	if t.FullDuplex() {
+		return t.duplexSendAndReceive(ctx, uri, odata, t.dial)
+	}
 	connEntry := t.ConnPool.Get(uri)
 	defer func() {
 		if err != nil {
@@ -121,27 +158,9 @@ func (t *unixTransporter) SendAndReceive(uri string, odata []byte) (idata []byte
 begin:
 	conn := connEntry.Get()
 	if conn == nil {
-		scheme, path := parseUnixUri(uri)
-		var unixaddr *net.UnixAddr
-		if unixaddr, err = net.ResolveUnixAddr(scheme, path); err != nil {
-			return nil, err
-		}
-		if conn, err = net.DialUnix(scheme, nil, unixaddr); err != nil {
+		if conn, err = t.dial(ctx, uri); err != nil {
 			return nil, err
 		}
-		if t.readBuffer != nil {
-			if err = conn.(*net.UnixConn).SetReadBuffer(t.readBuffer.(int)); err != nil {
-				return nil, err
-			}
-		}
-		if t.writeBuffer != nil {
-			if err = conn.(*net.UnixConn).SetWriteBuffer(t.writeBuffer.(int)); err != nil {
-				return nil, err
-			}
-		}
-		if t.tlsConfig != nil {
-			conn = tls.Client(conn, t.tlsConfig)
-		}
 		connEntry.Set(conn)
 	}
 	if t.timeout != nil {
@@ -153,20 +172,7 @@ begin:
 			goto begin
 		}
 	}
-	if t.writeTimeout != nil {
-		if err = conn.SetWriteDeadline(time.Now().Add(t.writeTimeout.(time.Duration))); err != nil {
-			return nil, err
-		}
-	}
-	if err = sendDataOverStream(conn, odata); err != nil {
-		return nil, err
-	}
-	if t.readTimeout != nil {
-		if err = conn.SetReadDeadline(time.Now().Add(t.readTimeout.(time.Duration))); err != nil {
-			return nil, err
-		}
-	}
-	if idata, err = receiveDataOverStream(conn); err != nil {
+	if idata, err = t.exchange(ctx, conn, odata); err != nil {
 		return nil, err
 	}
 	t.ConnPool.Free(connEntry)
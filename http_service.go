@@ -12,7 +12,7 @@
  *                                                        *
  * hprose http service for Go.                            *
  *                                                        *
- * LastModified: Jul 4, 2015                              *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -20,6 +20,7 @@
 package hprose
 
 import (
+	"encoding/json"
 	"io"
 	"io/ioutil"
 	"math/rand"
@@ -43,6 +44,8 @@ type HttpService struct {
 	P3PEnabled                   bool
 	GetEnabled                   bool
 	CrossDomainEnabled           bool
+	OpenRPCPath                  string
+	OpenRPCInfo                  OpenRPCInfo
 	accessControlAllowOrigins    map[string]bool
 	lastModified                 string
 	etag                         string
@@ -82,6 +85,7 @@ func NewHttpService() (service *HttpService) {
 	service.P3PEnabled = true
 	service.GetEnabled = true
 	service.CrossDomainEnabled = true
+	service.OpenRPCInfo = OpenRPCInfo{Title: "hprose", Version: "1.0.0"}
 	service.accessControlAllowOrigins = make(map[string]bool)
 	service.lastModified = t.Format(time.RFC1123)
 	service.etag = `"` + strconv.FormatInt(rand.Int63(), 16) + `"`
@@ -216,6 +220,29 @@ func (service *HttpService) readAll(request *http.Request) (data []byte, err err
 	return make([]byte, 0), nil
 }
 
+func (service *HttpService) describeHandler(response http.ResponseWriter) {
+	service.jsonHandler(response, service.Describe())
+}
+
+func (service *HttpService) openRPCHandler(response http.ResponseWriter, request *http.Request) bool {
+	if request.Method == "GET" && request.URL.Path == service.OpenRPCPath {
+		service.jsonHandler(response, service.OpenRPC(service.OpenRPCInfo))
+		return true
+	}
+	return false
+}
+
+func (service *HttpService) jsonHandler(response http.ResponseWriter, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		response.WriteHeader(500)
+		return
+	}
+	response.Header().Set("Content-Type", "application/json")
+	response.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	response.Write(data)
+}
+
 // Serve ...
 func (service *HttpService) Serve(response http.ResponseWriter, request *http.Request, userData map[string]interface{}) {
 	if service.clientAccessPolicyXmlContent != nil && service.clientAccessPolicyXmlHandler(response, request) {
@@ -224,10 +251,14 @@ func (service *HttpService) Serve(response http.ResponseWriter, request *http.Re
 	if service.crossDomainXmlContent != nil && service.crossDomainXmlHandler(response, request) {
 		return
 	}
+	if service.OpenRPCPath != "" && service.openRPCHandler(response, request) {
+		return
+	}
 	context := new(HttpContext)
 	context.BaseContext = NewBaseContext()
 	context.Response = response
 	context.Request = request
+	context.SetContext(request.Context())
 	if userData != nil {
 		for k, v := range userData {
 			context.SetInterface(k, v)
@@ -237,15 +268,23 @@ func (service *HttpService) Serve(response http.ResponseWriter, request *http.Re
 	switch request.Method {
 	case "GET":
 		if service.GetEnabled {
-			response.Write(service.doFunctionList(context))
+			if _, ok := request.URL.Query()["describe"]; ok && service.DescribeEnabled {
+				service.describeHandler(response)
+				return
+			}
+			response.Write(service.outputFilter(service.doFunctionList(context), context))
 		} else {
 			response.WriteHeader(403)
 		}
 	case "POST":
+		if codec := service.GetCodec(request.Header.Get("Content-Type")); codec != nil {
+			context.SetInterface(codecContextKey, codec)
+			response.Header().Set("Content-Type", codec.ContentType())
+		}
 		data, err := service.readAll(request)
 		request.Body.Close()
 		if err != nil {
-			response.Write(service.sendError(err, context))
+			response.Write(service.outputFilter(service.sendError(err, context), context))
 		}
 		response.Write(service.Handle(data, context))
 	}
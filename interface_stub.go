@@ -0,0 +1,184 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/interface_stub.go                               *
+ *                                                        *
+ * hprose interface stub for Go.                          *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"reflect"
+	"sync"
+)
+
+// MethodOptions is the options of the method in the service interface,
+// it takes the place of the tags on the func fields of the struct stub.
+type MethodOptions struct {
+	Name       string      // the remote method name, default is the method name
+	ByRef      interface{} // true, false, nil
+	SimpleMode interface{} // true, false, nil
+	ResultMode ResultMode
+	Oneway     bool // the invoking expects no response
+	Idempotent bool // the invoking is safe to retry
+}
+
+// InterfaceOptions is the companion options table of the service interface,
+// the key is the method name of the interface.
+type InterfaceOptions map[string]MethodOptions
+
+// Stub holds the remote methods which are created for the service interface
+type Stub struct {
+	funcs map[string]interface{}
+}
+
+// Func returns the remote method by the method name of the interface,
+// the type of the remote method is the same as the method of the interface.
+func (stub *Stub) Func(name string) interface{} {
+	return stub.funcs[name]
+}
+
+// ProxyFactory creates the proxy which implements the service interface
+// by the remote methods in stub.
+type ProxyFactory func(stub *Stub) interface{}
+
+var proxyFactories = struct {
+	sync.RWMutex
+	factories map[reflect.Type]ProxyFactory
+}{factories: make(map[reflect.Type]ProxyFactory)}
+
+// RegisterProxy register the proxy factory of the service interface,
+// iface is a nil pointer to the interface, such as (*Calculator)(nil).
+//
+// Go can't create the types which have methods at run time, so the proxy
+// type must be defined in code, the methods of the proxy simply call the
+// remote methods that the client creates by reflection.
+func RegisterProxy(iface interface{}, factory ProxyFactory) {
+	t := interfaceType(iface)
+	proxyFactories.Lock()
+	proxyFactories.factories[t] = factory
+	proxyFactories.Unlock()
+}
+
+func getProxyFactory(t reflect.Type) (factory ProxyFactory) {
+	proxyFactories.RLock()
+	factory = proxyFactories.factories[t]
+	proxyFactories.RUnlock()
+	return factory
+}
+
+func interfaceType(iface interface{}) reflect.Type {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic("iface must be a pointer to interface.")
+	}
+	return t.Elem()
+}
+
+func isInterfacePointer(p interface{}) bool {
+	v := reflect.ValueOf(p)
+	if !v.IsValid() || v.Kind() != reflect.Ptr || v.IsNil() {
+		return false
+	}
+	t := v.Type().Elem()
+	return t.Kind() == reflect.Interface && t.NumMethod() > 0
+}
+
+func isStubPointer(p interface{}) bool {
+	return isStructPointer(p) || isInterfacePointer(p)
+}
+
+func (client *BaseClient) useStub(stub interface{}, ns string, options InterfaceOptions) {
+	if isInterfacePointer(stub) {
+		client.createInterfaceStub(stub, ns, options)
+	} else {
+		client.createStub(stub, ns)
+	}
+}
+
+func (client *BaseClient) createInterfaceStub(stub interface{}, ns string, options InterfaceOptions) {
+	v := reflect.ValueOf(stub).Elem()
+	t := v.Type()
+	factory := getProxyFactory(t)
+	if factory == nil {
+		panic("The proxy of " + t.String() + " is not registered.")
+	}
+	s := &Stub{make(map[string]interface{})}
+	n := t.NumMethod()
+	for i := 0; i < n; i++ {
+		m := t.Method(i)
+		opt := options[m.Name]
+		name := opt.Name
+		if name == "" {
+			name = m.Name
+		}
+		if ns != "" {
+			name = ns + "_" + name
+		}
+		invokeOptions := &InvokeOptions{ByRef: opt.ByRef, SimpleMode: opt.SimpleMode, ResultMode: opt.ResultMode, Oneway: opt.Oneway, Idempotent: opt.Idempotent}
+		s.funcs[m.Name] = reflect.MakeFunc(m.Type, client.remoteMethod(m.Type, name, invokeOptions)).Interface()
+	}
+	proxy := reflect.ValueOf(factory(s))
+	if !proxy.IsValid() || !proxy.Type().Implements(t) {
+		panic("The proxy doesn't implement " + t.String() + ".")
+	}
+	v.Set(proxy)
+}
+
+// AddInterfaceMethods publish the methods of obj which are declared in the
+// service interface, iface is a nil pointer to the interface, such as
+// (*Calculator)(nil).
+// options is the same as AddFuntion, and an InterfaceOptions can be in it,
+// the Name, SimpleMode and ResultMode in it are used for the methods.
+func (methods *Methods) AddInterfaceMethods(iface interface{}, obj interface{}, options ...interface{}) {
+	if obj == nil {
+		panic("obj can't be nil")
+	}
+	t := interfaceType(iface)
+	v := reflect.ValueOf(obj)
+	if !v.Type().Implements(t) {
+		panic("obj doesn't implement " + t.String())
+	}
+	var table InterfaceOptions
+	opts := make([]interface{}, 0, len(options))
+	for _, opt := range options {
+		if o, ok := opt.(InterfaceOptions); ok {
+			table = o
+		} else {
+			opts = append(opts, opt)
+		}
+	}
+	n := t.NumMethod()
+	for i := 0; i < n; i++ {
+		name := t.Method(i).Name
+		method := v.MethodByName(name)
+		opt, ok := table[name]
+		if !ok {
+			methods.AddFunction(name, method.Interface(), opts...)
+			continue
+		}
+		if opt.Name != "" {
+			name = opt.Name
+		}
+		mopts := opts
+		if opt.ResultMode != Normal {
+			mopts = append(mopts[:len(mopts):len(mopts)], opt.ResultMode)
+		}
+		if simple, ok := opt.SimpleMode.(bool); ok {
+			mopts = append(mopts[:len(mopts):len(mopts)], simple)
+		}
+		methods.AddFunction(name, method.Interface(), mopts...)
+	}
+}
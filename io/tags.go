@@ -12,7 +12,7 @@
  *                                                        *
  * hprose tags enum for Go.                               *
  *                                                        *
- * LastModified: Jan 17, 2014                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -56,5 +56,6 @@ const (
 	TagResult    byte = 'R'
 	TagArgument  byte = 'A'
 	TagError     byte = 'E'
+	TagHeader    byte = 'H'
 	TagEnd       byte = 'z'
 )
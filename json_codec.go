@@ -0,0 +1,571 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/json_codec.go                                   *
+ *                                                        *
+ * hprose json codec for Go.                              *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// JSONCodec is the JSON-RPC 2.0 codec.
+//
+// The requests and the responses are JSON-RPC 2.0 objects, or arrays for the
+// batches. The struct fields are named by the field aliases like the hprose
+// format, so the tag registered by ClassManager.Register (such as json) is
+// used. The calls with ByRef have "byref":true in the request and get the
+// arguments in "args" of the response.
+type JSONCodec struct {
+	id int64
+}
+
+// NewJSONCodec is the constructor of JSONCodec
+func NewJSONCodec() *JSONCodec {
+	return new(JSONCodec)
+}
+
+var (
+	jsonMarshalerType   = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	jsonNull            = []byte("null")
+)
+
+// ContentType of JSONCodec
+func (codec *JSONCodec) ContentType() string {
+	return "application/json"
+}
+
+// Marshal encodes v to JSON
+func (codec *JSONCodec) Marshal(v reflect.Value) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := codec.encode(buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (codec *JSONCodec) encode(buf *bytes.Buffer, v reflect.Value) (err error) {
+	if !v.IsValid() {
+		_, err = buf.Write(jsonNull)
+		return err
+	}
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) ||
+		v.CanAddr() && (reflect.PtrTo(t).Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)) {
+		if (t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface) && v.IsNil() {
+			_, err = buf.Write(jsonNull)
+			return err
+		}
+		if v.CanAddr() {
+			v = v.Addr()
+		}
+		return codec.encodeByJSON(buf, v.Interface())
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.String:
+		return codec.encodeByJSON(buf, v.Interface())
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			_, err = buf.Write(jsonNull)
+			return err
+		}
+		return codec.encode(buf, v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			_, err = buf.Write(jsonNull)
+			return err
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return codec.encodeByJSON(buf, base64.StdEncoding.EncodeToString(v.Bytes()))
+		}
+		return codec.encodeList(buf, v)
+	case reflect.Array:
+		return codec.encodeList(buf, v)
+	case reflect.Map:
+		if v.IsNil() {
+			_, err = buf.Write(jsonNull)
+			return err
+		}
+		return codec.encodeMap(buf, v)
+	case reflect.Struct:
+		return codec.encodeStruct(buf, v)
+	}
+	return errors.New("the type " + t.String() + " isn't supported by JSONCodec")
+}
+
+func (codec *JSONCodec) encodeByJSON(buf *bytes.Buffer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err == nil {
+		_, err = buf.Write(data)
+	}
+	return err
+}
+
+func (codec *JSONCodec) encodeList(buf *bytes.Buffer, v reflect.Value) (err error) {
+	buf.WriteByte('[')
+	n := v.Len()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err = codec.encode(buf, v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return buf.WriteByte(']')
+}
+
+func (codec *JSONCodec) encodeMap(buf *bytes.Buffer, v reflect.Value) (err error) {
+	keys := v.MapKeys()
+	names := make([]string, len(keys))
+	index := make(map[string]reflect.Value, len(keys))
+	for i, key := range keys {
+		if key.Kind() == reflect.Interface {
+			key = key.Elem()
+		}
+		if key.Kind() == reflect.String {
+			names[i] = key.String()
+		} else {
+			var data []byte
+			if data, err = codec.Marshal(key); err != nil {
+				return err
+			}
+			names[i] = strings.Trim(string(data), `"`)
+		}
+		index[names[i]] = keys[i]
+	}
+	sort.Strings(names)
+	buf.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err = codec.encodeByJSON(buf, name); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err = codec.encode(buf, v.MapIndex(index[name])); err != nil {
+			return err
+		}
+	}
+	return buf.WriteByte('}')
+}
+
+func (codec *JSONCodec) encodeStruct(buf *bytes.Buffer, v reflect.Value) (err error) {
+	buf.WriteByte('{')
+	first := true
+	for _, f := range getFieldCache(v.Type()).fields {
+		fv, ok := fieldByIndex(v, f.Index, false)
+		if !ok {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		if err = codec.encodeByJSON(buf, f.Name); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err = codec.encode(buf, fv); err != nil {
+			return err
+		}
+	}
+	return buf.WriteByte('}')
+}
+
+// fieldByIndex returns the field of the struct v, the nil embedded pointers
+// are allocated when alloc is true, or the field isn't found.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	v = v.Field(index[0])
+	for _, i := range index[1:] {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return v, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// Unmarshal decodes the JSON data into v
+func (codec *JSONCodec) Unmarshal(data []byte, v reflect.Value) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, jsonNull) {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	t := v.Type()
+	if v.CanAddr() {
+		pt := reflect.PtrTo(t)
+		if pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+			return json.Unmarshal(data, v.Addr().Interface())
+		}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return codec.Unmarshal(data, v.Elem())
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			break
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		var e interface{}
+		if err := decoder.Decode(&e); err != nil {
+			return err
+		}
+		if e = jsonNumbers(e); e != nil {
+			v.Set(reflect.ValueOf(e))
+		}
+		return nil
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i := range items {
+			if err := codec.Unmarshal(items[i], slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Array:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		for i := 0; i < len(items) && i < v.Len(); i++ {
+			if err := codec.Unmarshal(items[i], v.Index(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		return codec.decodeMap(data, v)
+	case reflect.Struct:
+		return codec.decodeStruct(data, v)
+	}
+	return json.Unmarshal(data, v.Addr().Interface())
+}
+
+func (codec *JSONCodec) decodeMap(data []byte, v reflect.Value) error {
+	var items map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	t := v.Type()
+	m := reflect.MakeMapWithSize(t, len(items))
+	for name, item := range items {
+		key := reflect.New(t.Key()).Elem()
+		switch t.Key().Kind() {
+		case reflect.String:
+			key.SetString(name)
+		case reflect.Interface:
+			key.Set(reflect.ValueOf(name))
+		default:
+			if err := codec.Unmarshal([]byte(name), key); err != nil {
+				return err
+			}
+		}
+		value := reflect.New(t.Elem()).Elem()
+		if err := codec.Unmarshal(item, value); err != nil {
+			return err
+		}
+		m.SetMapIndex(key, value)
+	}
+	v.Set(m)
+	return nil
+}
+
+func (codec *JSONCodec) decodeStruct(data []byte, v reflect.Value) error {
+	var items map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	indexMap := getIndexCache(v.Type())
+	for name, item := range items {
+		if index, ok := indexMap[strings.ToLower(name)]; ok {
+			f, _ := fieldByIndex(v, index, true)
+			if err := codec.Unmarshal(item, f); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type jsonCodecRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ByRef   bool            `json:"byref,omitempty"`
+}
+
+type jsonCodecError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+type jsonCodecResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonCodecError `json:"error,omitempty"`
+	Args    json.RawMessage `json:"args,omitempty"`
+}
+
+// EncodeRequest encodes the calls as JSON-RPC requests, the calls which
+// aren't Oneway get the ids
+func (codec *JSONCodec) EncodeRequest(calls []*CodecCall) ([]byte, error) {
+	requests := make([]*jsonCodecRequest, len(calls))
+	for i, call := range calls {
+		request := &jsonCodecRequest{JSONRPC: "2.0", Method: call.Name, ByRef: call.ByRef}
+		if !call.Oneway {
+			id := atomic.AddInt64(&codec.id, 1)
+			call.ID = id
+			request.ID = json.RawMessage(strconv.FormatInt(id, 10))
+		}
+		request.Params = joinJSON(call.Args)
+		requests[i] = request
+	}
+	if len(requests) == 1 && !calls[0].Batch {
+		return json.Marshal(requests[0])
+	}
+	return json.Marshal(requests)
+}
+
+// DecodeRequest decodes the JSON-RPC request or batch, the invalid requests
+// in a batch get their own errors by the Error of the calls
+func (codec *JSONCodec) DecodeRequest(data []byte) ([]*CodecCall, error) {
+	data = bytes.TrimSpace(data)
+	var items []json.RawMessage
+	batch := len(data) > 0 && data[0] == '['
+	if batch {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, &RemoteError{Code: JSONRPCParseError, Message: err.Error()}
+		}
+		if len(items) == 0 {
+			return nil, &RemoteError{Code: JSONRPCInvalidRequest, Message: "empty batch"}
+		}
+	} else {
+		items = []json.RawMessage{data}
+	}
+	calls := make([]*CodecCall, len(items))
+	for i, item := range items {
+		var request jsonCodecRequest
+		if err := json.Unmarshal(item, &request); err != nil {
+			code := JSONRPCInvalidRequest
+			if _, ok := err.(*json.SyntaxError); ok {
+				code = JSONRPCParseError
+			}
+			calls[i] = &CodecCall{Batch: batch, Results: 1, Error: &RemoteError{Code: code, Message: err.Error()}}
+			continue
+		}
+		call := &CodecCall{Name: request.Method, ByRef: request.ByRef, Batch: batch, Results: 1}
+		if request.ID == nil {
+			call.Oneway = true
+		} else {
+			call.ID = request.ID
+		}
+		params := bytes.TrimSpace(request.Params)
+		if len(params) > 0 && params[0] == '{' {
+			var named map[string]json.RawMessage
+			if err := json.Unmarshal(params, &named); err != nil {
+				call.Oneway = false
+				call.Error = &RemoteError{Code: JSONRPCParseError, Message: err.Error()}
+			} else {
+				call.Named = make(map[string][]byte, len(named))
+				for name, value := range named {
+					call.Named[name] = value
+				}
+				call.Args = [][]byte{params}
+			}
+		} else if len(params) > 0 && !bytes.Equal(params, jsonNull) {
+			var err error
+			if call.Args, err = splitJSON(params); err != nil {
+				call.Oneway = false
+				call.Error = &RemoteError{Code: JSONRPCInvalidRequest, Message: err.Error()}
+			}
+		}
+		calls[i] = call
+	}
+	return calls, nil
+}
+
+// EncodeResponse encodes the replies as JSON-RPC responses, the Oneway calls
+// get no response
+func (codec *JSONCodec) EncodeResponse(calls []*CodecCall, replies []*CodecReply) ([]byte, error) {
+	responses := make([]*jsonCodecResponse, 0, len(calls))
+	for i, call := range calls {
+		if call.Oneway {
+			continue
+		}
+		reply := replies[i]
+		response := &jsonCodecResponse{JSONRPC: "2.0", ID: jsonNull}
+		if id, ok := call.ID.(json.RawMessage); ok {
+			response.ID = id
+		}
+		if e := reply.Error; e != nil {
+			response.Error = &jsonCodecError{Code: e.Code, Message: e.Message}
+			if e.Code == 0 {
+				response.Error.Code = JSONRPCInternalError
+			}
+			if e.Data != nil {
+				data, err := codec.Marshal(reflect.ValueOf(e.Data))
+				if err != nil {
+					return nil, err
+				}
+				response.Error.Data = data
+			}
+		} else {
+			if len(reply.Results) == 1 {
+				response.Result = reply.Results[0]
+			} else if len(reply.Results) == 0 {
+				response.Result = jsonNull
+			} else {
+				response.Result = joinJSON(reply.Results)
+			}
+			if call.ByRef {
+				response.Args = joinJSON(reply.Args)
+			}
+		}
+		responses = append(responses, response)
+	}
+	switch {
+	case len(responses) == 0:
+		return []byte{}, nil
+	case len(calls) == 1 && !calls[0].Batch:
+		return json.Marshal(responses[0])
+	}
+	return json.Marshal(responses)
+}
+
+// DecodeResponse decodes the JSON-RPC response or batch, the responses are
+// matched to the calls by the ids
+func (codec *JSONCodec) DecodeResponse(data []byte, calls []*CodecCall) ([]*CodecReply, error) {
+	data = bytes.TrimSpace(data)
+	var items []json.RawMessage
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+	} else if len(data) > 0 {
+		items = []json.RawMessage{data}
+	}
+	responses := make(map[string]*jsonCodecResponse, len(items))
+	var failure *jsonCodecResponse
+	for _, item := range items {
+		response := new(jsonCodecResponse)
+		if err := json.Unmarshal(item, response); err != nil {
+			return nil, err
+		}
+		if id := string(response.ID); id == "" || id == "null" {
+			failure = response
+		} else {
+			responses[id] = response
+		}
+	}
+	replies := make([]*CodecReply, len(calls))
+	for i, call := range calls {
+		reply := new(CodecReply)
+		replies[i] = reply
+		if call.Oneway {
+			continue
+		}
+		id := strconv.FormatInt(call.ID.(int64), 10)
+		response, ok := responses[id]
+		if !ok {
+			if response = failure; response == nil {
+				return nil, errors.New("Wrong Response: no response for id " + id)
+			}
+		}
+		if e := response.Error; e != nil {
+			reply.Error = &RemoteError{Code: e.Code, Message: e.Message}
+			if e.Data != nil {
+				var d interface{}
+				if err := codec.Unmarshal(e.Data, reflect.ValueOf(&d).Elem()); err != nil {
+					return nil, err
+				}
+				reply.Error.Data = d
+			}
+			continue
+		}
+		var err error
+		if call.Results == 1 {
+			reply.Results = [][]byte{response.Result}
+		} else if reply.Results, err = splitJSON(response.Result); err != nil {
+			return nil, err
+		}
+		if call.ByRef {
+			if reply.Args, err = splitJSON(response.Args); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return replies, nil
+}
+
+// joinJSON joins the JSON values as an array
+func joinJSON(values [][]byte) json.RawMessage {
+	return json.RawMessage(append(append([]byte{'['}, bytes.Join(values, []byte{','})...), ']'))
+}
+
+// splitJSON splits the JSON array into the values, null is an empty array
+func splitJSON(data []byte) ([][]byte, error) {
+	if len(data) == 0 || bytes.Equal(data, jsonNull) {
+		return nil, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(items))
+	for i := range items {
+		values[i] = items[i]
+	}
+	return values, nil
+}
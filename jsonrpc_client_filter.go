@@ -12,7 +12,7 @@
  *                                                        *
  * jsonrpc client filter for Go.                          *
  *                                                        *
- * LastModified: May 22, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -22,59 +22,152 @@ package hprose
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
 )
 
-var id = 1
+// jsonrpcID is the id counter of the JSONRPCClientFilter which isn't created
+// by NewJSONRPCClientFilter
+var jsonrpcID int64
 
 // JSONRPCClientFilter is a JSONRPC Client Filter
+//
+// Every request gets an id which is unique in the client, and the id of the
+// response is verified. The invokings with the Oneway option are sent as
+// notifications, and the invokings of a batch are sent as a batch array.
 type JSONRPCClientFilter struct {
 	Version string
+	id      *int64
+}
+
+type jsonrpcCall struct {
+	id           int64
+	notification bool
+}
+
+type jsonrpcCalls struct {
+	calls []jsonrpcCall
+	batch bool
 }
 
 // NewJSONRPCClientFilter is a constructor for JSONRPCClientFilter
 func NewJSONRPCClientFilter(version string) JSONRPCClientFilter {
 	if version == "1.0" || version == "1.1" || version == "2.0" {
-		return JSONRPCClientFilter{Version: version}
+		return JSONRPCClientFilter{Version: version, id: new(int64)}
 	}
 	panic("version must be 1.0, 1.1 or 2.0 in string format.")
 }
 
+func (filter JSONRPCClientFilter) nextID() int64 {
+	if filter.id == nil {
+		return atomic.AddInt64(&jsonrpcID, 1)
+	}
+	return atomic.AddInt64(filter.id, 1)
+}
+
 // InputFilter for JSONRPC Client
 func (filter JSONRPCClientFilter) InputFilter(data []byte, context Context) []byte {
-	var response map[string]interface{}
-	if err := json.Unmarshal(data, &response); err != nil {
+	v, ok := context.GetInterface("jsonrpc")
+	if !ok {
 		return data
 	}
-	result := response["result"]
-	err := response["error"]
+	state := v.(*jsonrpcCalls)
 	buf := new(bytes.Buffer)
 	writer := NewWriter(buf, true)
-	if err != nil {
-		e := err.(map[string]interface{})
-		buf.WriteByte(TagError)
-		writer.WriteString(e["message"].(string))
+	responses := make(map[string]map[string]interface{})
+	var failure map[string]interface{}
+	if !isJSONRPC(data) {
+		if !filter.allNotifications(state) {
+			return data
+		}
 	} else {
-		buf.WriteByte(TagResult)
-		writer.Serialize(result)
+		var response interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&response); err != nil {
+			writeError(writer, &RemoteError{Code: JSONRPCParseError, Message: err.Error()})
+			buf.WriteByte(TagEnd)
+			return buf.Bytes()
+		}
+		list, ok := response.([]interface{})
+		if !ok {
+			list = []interface{}{response}
+		}
+		for _, item := range list {
+			r, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if id, ok := r["id"].(json.Number); ok {
+				responses[id.String()] = r
+			} else if r["error"] != nil {
+				// the service can't read the id of the invalid request
+				failure = r
+			}
+		}
+	}
+	for _, call := range state.calls {
+		writer.Reset()
+		if call.notification {
+			buf.WriteByte(TagResult)
+			writer.WriteNull()
+			continue
+		}
+		response, ok := responses[strconv.FormatInt(call.id, 10)]
+		if !ok {
+			if response = failure; response == nil {
+				writeError(writer, &RemoteError{
+					Code:    JSONRPCInternalError,
+					Message: "Wrong Response: no response for id " + strconv.FormatInt(call.id, 10),
+				})
+				continue
+			}
+		}
+		if e := response["error"]; e != nil {
+			writeError(writer, newJSONRPCRemoteError(e))
+		} else {
+			buf.WriteByte(TagResult)
+			writer.Serialize(jsonNumbers(response["result"]))
+		}
 	}
 	buf.WriteByte(TagEnd)
-	data = buf.Bytes()
-	return data
+	return buf.Bytes()
+}
+
+func (filter JSONRPCClientFilter) allNotifications(state *jsonrpcCalls) bool {
+	for _, call := range state.calls {
+		if !call.notification {
+			return false
+		}
+	}
+	return true
 }
 
 // OutputFilter for JSONRPC Client
 func (filter JSONRPCClientFilter) OutputFilter(data []byte, context Context) []byte {
-	request := make(map[string]interface{})
-	if filter.Version == "1.1" {
-		request["version"] = "1.1"
-	} else if filter.Version == "2.0" {
-		request["jsonrpc"] = "2.0"
+	var oneway []bool
+	if v, ok := context.GetInterface(onewayContextKey); ok {
+		oneway, _ = v.([]bool)
 	}
 	istream := NewBytesReader(data)
 	reader := NewReader(istream, false)
 	reader.JSONCompatible = true
+	state := new(jsonrpcCalls)
+	requests := make([]interface{}, 0, len(oneway))
 	tag, _ := istream.ReadByte()
-	if tag == TagCall {
+	if tag == TagHeader {
+		reader.ReadRaw()
+		tag, _ = istream.ReadByte()
+	}
+	for tag == TagCall {
+		request := make(map[string]interface{})
+		if filter.Version == "1.1" {
+			request["version"] = "1.1"
+		} else if filter.Version == "2.0" {
+			request["jsonrpc"] = "2.0"
+		}
+		reader.Reset()
 		request["method"], _ = reader.ReadString()
 		tag, _ = istream.ReadByte()
 		if tag == TagList {
@@ -86,10 +179,66 @@ func (filter JSONRPCClientFilter) OutputFilter(data []byte, context Context) []b
 				}
 				request["params"] = params
 			}
+			// skip the closebrace of params and the byref flag
+			istream.ReadByte()
+			tag, _ = istream.ReadByte()
+			if tag == TagTrue {
+				tag, _ = istream.ReadByte()
+			}
+		}
+		var call jsonrpcCall
+		if n := len(state.calls); n < len(oneway) && oneway[n] {
+			call.notification = true
+			if filter.Version == "1.0" || filter.Version == "" {
+				request["id"] = nil
+			}
+		} else {
+			call.id = filter.nextID()
+			request["id"] = call.id
 		}
+		state.calls = append(state.calls, call)
+		requests = append(requests, request)
+	}
+	context.SetInterface("jsonrpc", state)
+	if len(requests) == 1 {
+		data, _ = json.Marshal(requests[0])
+	} else {
+		state.batch = true
+		data, _ = json.Marshal(requests)
 	}
-	request["id"] = id
-	id++
-	data, _ = json.Marshal(request)
 	return data
 }
+
+func newJSONRPCRemoteError(err interface{}) *RemoteError {
+	e, ok := err.(map[string]interface{})
+	if !ok {
+		return &RemoteError{Code: JSONRPCInternalError, Message: fmt.Sprint(err)}
+	}
+	var code int64
+	if c, ok := e["code"].(json.Number); ok {
+		code, _ = c.Int64()
+	}
+	message, _ := e["message"].(string)
+	return &RemoteError{Code: int(code), Message: message, Data: jsonNumbers(e["data"])}
+}
+
+// jsonNumbers converts the json.Number in v to int64 or float64
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for i := range v {
+			v[i] = jsonNumbers(v[i])
+		}
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = jsonNumbers(v[k])
+		}
+	}
+	return v
+}
@@ -12,7 +12,7 @@
  *                                                        *
  * jsonrpc service filter for Go.                         *
  *                                                        *
- * LastModified: May 25, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -22,130 +22,322 @@ package hprose
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
+	"strings"
 )
 
+// The error codes of JSON-RPC 2.0
+const (
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+)
+
+var jsonrpcMessages = map[int]string{
+	JSONRPCParseError:     "Parse error",
+	JSONRPCInvalidRequest: "Invalid Request",
+	JSONRPCMethodNotFound: "Method not found",
+	JSONRPCInvalidParams:  "Invalid params",
+	JSONRPCInternalError:  "Internal error",
+}
+
 // JSONRPCServiceFilter is a JSONRPC Service Filter
+//
+// It supports JSON-RPC 1.0, 1.1 and 2.0. The requests without id (or with
+// the null id for 1.0 and 1.1) are notifications which get no response,
+// and the named params are mapped onto the parameters by the ParamNames
+// option of AddFunction, or passed as the only struct or map parameter.
 type JSONRPCServiceFilter struct{}
 
+type jsonrpcRequest struct {
+	id           interface{}
+	version      string
+	notification bool
+	err          map[string]interface{}
+}
+
+type jsonrpcState struct {
+	requests []*jsonrpcRequest
+	batch    bool
+}
+
+func newJSONRPCError(code int, data interface{}) map[string]interface{} {
+	e := map[string]interface{}{"code": code, "message": jsonrpcMessages[code]}
+	if data != nil {
+		e["data"] = data
+	}
+	return e
+}
+
+func isJSONRPC(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && (data[0] == '[' || data[0] == '{')
+}
+
 // InputFilter for JSONRPC Service
 func (filter JSONRPCServiceFilter) InputFilter(data []byte, context Context) []byte {
-	if (len(data) > 0) && (data[0] == '[' || data[0] == '{') {
-		var requests []map[string]interface{}
-		if data[0] == '[' {
-			if err := json.Unmarshal(data, &requests); err != nil {
-				return data
-			}
-		} else {
-			requests = make([]map[string]interface{}, 1)
-			if err := json.Unmarshal(data, &requests[0]); err != nil {
-				return data
-			}
+	if !isJSONRPC(data) {
+		return data
+	}
+	state := new(jsonrpcState)
+	context.SetInterface("jsonrpc", state)
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		state.requests = []*jsonrpcRequest{{
+			version: "2.0",
+			err:     newJSONRPCError(JSONRPCParseError, err.Error()),
+		}}
+		return []byte{TagEnd}
+	}
+	var service *BaseService
+	if s, ok := context.GetInterface(serviceContextKey); ok {
+		service, _ = s.(*BaseService)
+	}
+	requests, batch := v.([]interface{})
+	if !batch {
+		requests = []interface{}{v}
+	} else if len(requests) == 0 {
+		requests = []interface{}{nil}
+		batch = false
+	}
+	state.batch = batch
+	state.requests = make([]*jsonrpcRequest, len(requests))
+	buf := new(bytes.Buffer)
+	writer := NewWriter(buf, true)
+	for i, request := range requests {
+		state.requests[i] = filter.parseRequest(request, service, writer)
+	}
+	buf.WriteByte(TagEnd)
+	return buf.Bytes()
+}
+
+// parseRequest validates the request and writes it as hprose call
+func (filter JSONRPCServiceFilter) parseRequest(v interface{}, service *BaseService, writer *Writer) *jsonrpcRequest {
+	r := &jsonrpcRequest{version: "2.0"}
+	request, ok := v.(map[string]interface{})
+	if !ok {
+		r.err = newJSONRPCError(JSONRPCInvalidRequest, nil)
+		return r
+	}
+	version := "2.0"
+	if v, ok := request["jsonrpc"]; ok {
+		if v != "2.0" {
+			r.err = newJSONRPCError(JSONRPCInvalidRequest, "jsonrpc must be \"2.0\"")
+			return r
 		}
-		buf := new(bytes.Buffer)
-		writer := NewWriter(buf, true)
-		n := len(requests)
-		jsonrpc := make([]map[string]interface{}, n)
-		for i, request := range requests {
-			j := make(map[string]interface{})
-			if id, ok := request["id"]; ok {
-				j["id"] = id
-			} else {
-				j["id"] = nil
-			}
-			if version, ok := request["version"]; ok {
-				j["version"] = version
-			} else if jsonrpc, ok := request["jsonrpc"]; ok {
-				j["version"] = jsonrpc
-			} else {
-				j["version"] = "1.0"
-			}
-			jsonrpc[i] = j
-			if method, ok := request["method"].(string); ok && method != "" {
-				buf.WriteByte(TagCall)
-				writer.WriteString(method)
-				if params, ok := request["params"].([]interface{}); ok && params != nil && len(params) > 0 {
-					writer.Serialize(params)
+	} else if v, ok := request["version"].(string); ok {
+		version = v
+	} else {
+		version = "1.0"
+	}
+	id, hasID := request["id"]
+	switch id.(type) {
+	case nil, string, float64:
+	default:
+		r.err = newJSONRPCError(JSONRPCInvalidRequest, "id must be a string, number or null")
+		return r
+	}
+	r.id = id
+	name, ok := request["method"].(string)
+	if !ok || name == "" {
+		r.err = newJSONRPCError(JSONRPCInvalidRequest, "method must be a string")
+		return r
+	}
+	// the invalid requests are responded in 2.0
+	r.version = version
+	if version == "2.0" {
+		r.notification = !hasID
+	} else {
+		// the notifications of 1.0 have the null id, and 1.1 have no id
+		r.notification = id == nil
+	}
+	var method *Method
+	if service != nil {
+		if method = service.getMethod(name); method == nil && service.RemoteMethods["*"] == nil {
+			r.err = newJSONRPCError(JSONRPCMethodNotFound, name)
+			return r
+		}
+	}
+	var args []interface{}
+	switch params := request["params"].(type) {
+	case nil:
+	case []interface{}:
+		args = params
+		if method != nil && !checkArgsCount(method.Function.Type(), len(args)) {
+			r.err = newJSONRPCError(JSONRPCInvalidParams, "wrong number of params")
+			return r
+		}
+	case map[string]interface{}:
+		var err string
+		if args, err = namedArgs(method, params); err != "" {
+			r.err = newJSONRPCError(JSONRPCInvalidParams, err)
+			return r
+		}
+	default:
+		r.err = newJSONRPCError(JSONRPCInvalidRequest, "params must be an array or object")
+		return r
+	}
+	writer.Stream.WriteByte(TagCall)
+	writer.WriteString(name)
+	if len(args) > 0 {
+		writer.Serialize(args)
+	}
+	return r
+}
+
+// paramTypes returns the types of the parameters which are read from the
+// request, and whether the last one can be filled by the ArgsFixer.
+func paramTypes(ft reflect.Type) (in []reflect.Type, fixable bool) {
+	in = make([]reflect.Type, 0, ft.NumIn())
+	for i := 0; i < ft.NumIn(); i++ {
+		in = append(in, ft.In(i))
+	}
+	if hasContextParam(ft) {
+		in = in[1:]
+	}
+	if n := len(in); n > 0 && !ft.IsVariadic() {
+		last := in[n-1]
+		fixable = isContextParam(last) || last.Kind() == reflect.Interface && last.NumMethod() == 0
+	}
+	return in, fixable
+}
+
+func checkArgsCount(ft reflect.Type, count int) bool {
+	in, fixable := paramTypes(ft)
+	n := len(in)
+	if ft.IsVariadic() {
+		return count >= n-1
+	}
+	return count == n || fixable && count == n-1
+}
+
+func namedArgs(method *Method, params map[string]interface{}) ([]interface{}, string) {
+	if method == nil {
+		return []interface{}{params}, ""
+	}
+	if names := method.ParamNames; names != nil {
+		args := make([]interface{}, len(names))
+	NEXT:
+		for name, value := range params {
+			for i := range names {
+				if strings.EqualFold(names[i], name) {
+					args[i] = value
+					continue NEXT
 				}
 			}
+			return nil, "unknown param " + name
 		}
-		buf.WriteByte(TagEnd)
-		data = buf.Bytes()
-		context.SetInterface("jsonrpc", jsonrpc)
+		return args, ""
 	}
-	return data
+	in, fixable := paramTypes(method.Function.Type())
+	if fixable {
+		in = in[:len(in)-1]
+	}
+	if len(in) == 1 {
+		t := in[0]
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct || t.Kind() == reflect.Map {
+			return []interface{}{params}, ""
+		}
+	}
+	return nil, "named params are not supported by " + method.Function.Type().String()
 }
 
-func initResponse(j map[string]interface{}, err interface{}) map[string]interface{} {
+func newJSONRPCResponse(r *jsonrpcRequest, result interface{}, err map[string]interface{}) map[string]interface{} {
 	response := make(map[string]interface{})
-	if version, ok := j["version"].(string); ok && version != "2.0" {
-		if version == "1.1" {
-			response["version"] = "1.1"
+	if r.version == "2.0" {
+		response["jsonrpc"] = "2.0"
+		if err != nil {
+			response["error"] = err
+		} else {
+			response["result"] = result
 		}
-		response["result"] = nil
-		response["error"] = err
 	} else {
-		response["jsonrpc"] = "2.0"
+		if r.version == "1.1" {
+			response["version"] = "1.1"
+		}
+		response["result"] = result
+		if err != nil {
+			response["error"] = err
+		} else {
+			response["error"] = nil
+		}
 	}
-	response["id"] = j["id"]
+	response["id"] = r.id
 	return response
 }
 
-func jsonrpcError(jsonrpc []map[string]interface{}, err error) []byte {
-	n := len(jsonrpc)
-	responses := make([]map[string]interface{}, n)
-	for i, j := range jsonrpc {
-		responses[i] = initResponse(j, err.Error())
+func readJSONRPCError(reader *Reader, istream *BytesReader) map[string]interface{} {
+	re, err := readError(reader, istream)
+	if err != nil {
+		return newJSONRPCError(JSONRPCInternalError, err.Error())
 	}
-	if n == 1 {
-		data, _ := json.Marshal(responses[0])
-		return data
+	e := map[string]interface{}{"code": JSONRPCInternalError, "message": re.Message}
+	if re.Code != 0 {
+		e["code"] = re.Code
 	}
-	data, _ := json.Marshal(responses)
-	return data
+	if re.Data != nil {
+		e["data"] = re.Data
+	}
+	return e
 }
 
 // OutputFilter for JSONRPC Service
 func (filter JSONRPCServiceFilter) OutputFilter(data []byte, context Context) []byte {
-	if jsonrpc, ok := context.GetInterface("jsonrpc"); ok {
-		jsonrpc := jsonrpc.([]map[string]interface{})
-		n := len(jsonrpc)
-		responses := make([]map[string]interface{}, n)
-		istream := NewBytesReader(data)
-		reader := NewReader(istream, false)
-		reader.JSONCompatible = true
-		tag, err := istream.ReadByte()
-		if err != nil {
-			return jsonrpcError(jsonrpc, err)
-		}
-		i := 0
-		for {
-			response := initResponse(jsonrpc[i], nil)
-			if tag == TagResult {
+	s, ok := context.GetInterface("jsonrpc")
+	if !ok {
+		return data
+	}
+	state := s.(*jsonrpcState)
+	istream := NewBytesReader(data)
+	reader := NewReader(istream, false)
+	reader.JSONCompatible = true
+	responses := make([]interface{}, 0, len(state.requests))
+	var lastError map[string]interface{}
+	for _, r := range state.requests {
+		var response map[string]interface{}
+		if r.err != nil {
+			response = newJSONRPCResponse(r, nil, r.err)
+		} else {
+			tag, err := istream.ReadByte()
+			switch {
+			case err != nil:
+				response = newJSONRPCResponse(r, nil, newJSONRPCError(JSONRPCInternalError, err.Error()))
+			case tag == TagResult:
 				reader.Reset()
 				var result interface{}
-				reader.Unserialize(&result)
-				response["result"] = result
-				tag, _ = istream.ReadByte()
-			} else if tag == TagError {
-				reader.Reset()
-				e := make(map[string]interface{})
-				e["code"] = -1
-				message, _ := reader.ReadString()
-				e["message"] = message
-				tag, _ = istream.ReadByte()
-			}
-			responses[i] = response
-			i++
-			if tag == TagEnd {
-				break
+				if err = reader.Unserialize(&result); err != nil {
+					response = newJSONRPCResponse(r, nil, newJSONRPCError(JSONRPCInternalError, err.Error()))
+				} else {
+					response = newJSONRPCResponse(r, result, nil)
+				}
+			case tag == TagError:
+				lastError = readJSONRPCError(reader, istream)
+				response = newJSONRPCResponse(r, nil, lastError)
+			default:
+				// the whole request failed with lastError, and there is no more result
+				istream.Pos--
+				if lastError == nil {
+					lastError = newJSONRPCError(JSONRPCInternalError, "no response")
+				}
+				response = newJSONRPCResponse(r, nil, lastError)
 			}
 		}
-		if n == 1 {
-			data, _ := json.Marshal(responses[0])
-			return data
+		if !r.notification {
+			responses = append(responses, response)
 		}
-		data, _ := json.Marshal(responses)
-		return data
+	}
+	switch {
+	case len(responses) == 0:
+		return []byte{}
+	case state.batch:
+		data, _ = json.Marshal(responses)
+	default:
+		data, _ = json.Marshal(responses[0])
 	}
 	return data
 }
@@ -0,0 +1,230 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/openrpc.go                                      *
+ *                                                        *
+ * hprose OpenRPC document for Go.                        *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose
+
+import (
+	"container/list"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenRPCVersion is the version of the OpenRPC specification
+const OpenRPCVersion = "1.2.6"
+
+// JSONSchema is the subset of JSON Schema used by the OpenRPC document
+type JSONSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Minimum              *float64               `json:"minimum,omitempty"`
+	Items                interface{}            `json:"items,omitempty"` // *JSONSchema or []*JSONSchema
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+}
+
+// OpenRPCContentDescriptor describes a parameter or the result
+type OpenRPCContentDescriptor struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Required    bool        `json:"required,omitempty"`
+	Schema      *JSONSchema `json:"schema"`
+}
+
+// OpenRPCMethod describes a method
+type OpenRPCMethod struct {
+	Name           string                      `json:"name"`
+	ParamStructure string                      `json:"paramStructure"`
+	Params         []*OpenRPCContentDescriptor `json:"params"`
+	Result         *OpenRPCContentDescriptor   `json:"result"`
+}
+
+// OpenRPCInfo is the metadata of the service
+type OpenRPCInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenRPCComponents holds the schemas of the classes
+type OpenRPCComponents struct {
+	Schemas map[string]*JSONSchema `json:"schemas"`
+}
+
+// OpenRPCDocument is the OpenRPC document of the service
+type OpenRPCDocument struct {
+	OpenRPC    string             `json:"openrpc"`
+	Info       OpenRPCInfo        `json:"info"`
+	Methods    []*OpenRPCMethod   `json:"methods"`
+	Components *OpenRPCComponents `json:"components,omitempty"`
+}
+
+var zeroMinimum = 0.0
+
+var timeType = reflect.TypeOf(time.Time{})
+var uuidType = reflect.TypeOf(UUID(nil))
+var bigIntType = reflect.TypeOf(big.Int{})
+var listType = reflect.TypeOf(list.List{})
+
+type schemaBuilder struct {
+	schemas map[string]*JSONSchema
+	classes map[reflect.Type]string
+}
+
+// OpenRPC returns the OpenRPC document of the published methods for the
+// JSON-RPC mode. The parameters are by-position and named by their
+// indexes, the properties of the classes are named by the field aliases
+// of ClassManager. The missing method is not included.
+func (methods *Methods) OpenRPC(info OpenRPCInfo) *OpenRPCDocument {
+	b := &schemaBuilder{make(map[string]*JSONSchema), make(map[reflect.Type]string)}
+	doc := &OpenRPCDocument{
+		OpenRPC: OpenRPCVersion,
+		Info:    info,
+		Methods: make([]*OpenRPCMethod, 0, len(methods.MethodNames)),
+	}
+	for _, name := range methods.MethodNames {
+		if name == "*" {
+			continue
+		}
+		if method := methods.RemoteMethods[strings.ToLower(name)]; method != nil {
+			doc.Methods = append(doc.Methods, b.method(name, method))
+		}
+	}
+	if len(b.schemas) > 0 {
+		doc.Components = &OpenRPCComponents{b.schemas}
+	}
+	return doc
+}
+
+func (b *schemaBuilder) method(name string, method *Method) *OpenRPCMethod {
+	ft := method.Function.Type()
+	names := method.ParamNames
+	m := &OpenRPCMethod{Name: name, ParamStructure: "by-position"}
+	if names != nil {
+		// the JSONRPCServiceFilter accepts the named params by ParamNames
+		m.ParamStructure = "either"
+	}
+	begin, end := 0, ft.NumIn()
+	if hasContextParam(ft) {
+		begin++
+	}
+	if end > begin && !ft.IsVariadic() && isContextParam(ft.In(end-1)) {
+		end--
+	}
+	m.Params = make([]*OpenRPCContentDescriptor, 0, end-begin)
+	for i := begin; i < end; i++ {
+		param := &OpenRPCContentDescriptor{
+			Name:     "arg" + strconv.Itoa(i-begin),
+			Required: true,
+			Schema:   b.schema(ft.In(i)),
+		}
+		if i-begin < len(names) {
+			param.Name = names[i-begin]
+		}
+		if ft.IsVariadic() && i == end-1 {
+			param.Description = "variadic"
+			param.Required = false
+			param.Schema = b.schema(ft.In(i).Elem())
+		}
+		m.Params = append(m.Params, param)
+	}
+	out := make([]*JSONSchema, 0, ft.NumOut())
+	for i := 0; i < ft.NumOut(); i++ {
+		if t := ft.Out(i); i < ft.NumOut()-1 || !t.Implements(errorType) {
+			out = append(out, b.schema(t))
+		}
+	}
+	m.Result = &OpenRPCContentDescriptor{Name: "result"}
+	switch len(out) {
+	case 0:
+		m.Result.Schema = &JSONSchema{Type: "null"}
+	case 1:
+		m.Result.Schema = out[0]
+	default:
+		m.Result.Schema = &JSONSchema{Type: "array", Items: out}
+	}
+	return m
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) *JSONSchema {
+	switch t {
+	case timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &JSONSchema{Type: "string", Format: "uuid"}
+	case bigIntType:
+		return &JSONSchema{Type: "integer"}
+	case bigFloatType:
+		return &JSONSchema{Type: "number"}
+	case bigRatType:
+		return &JSONSchema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &JSONSchema{Type: "integer", Minimum: &zeroMinimum}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Complex64, reflect.Complex128:
+		return &JSONSchema{Type: "array", Items: &JSONSchema{Type: "number"}}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Ptr:
+		return b.schema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t == listType {
+			return &JSONSchema{Type: "array", Items: &JSONSchema{}}
+		}
+		if t.Name() == "" {
+			return &JSONSchema{Type: "object"}
+		}
+		return &JSONSchema{Ref: "#/components/schemas/" + b.class(t)}
+	}
+	return &JSONSchema{}
+}
+
+func (b *schemaBuilder) class(t reflect.Type) string {
+	if name, ok := b.classes[t]; ok {
+		return name
+	}
+	name := ClassManager.GetClassAlias(t)
+	if name == "" {
+		name = t.Name()
+	}
+	b.classes[t] = name
+	schema := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+	b.schemas[name] = schema
+	for _, f := range getFieldCache(t).fields {
+		schema.Properties[f.Name] = b.schema(t.FieldByIndex(f.Index).Type)
+	}
+	return name
+}
@@ -12,7 +12,7 @@
  *                                                        *
  * hprose RawReader for Go.                               *
  *                                                        *
- * LastModified: Jun 3, 2015                              *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -172,7 +172,7 @@ func (r *RawReader) readGuidRaw(ostream BufWriter) (err error) {
 
 func (r *RawReader) readComplexRaw(ostream BufWriter) (err error) {
 	var tag byte
-	for tag != TagOpenbrace {
+	for err == nil && tag != TagOpenbrace {
 		if tag, err = r.Stream.ReadByte(); err == nil {
 			err = ostream.WriteByte(tag)
 		}
@@ -12,7 +12,7 @@
  *                                                        *
  * hprose Reader for Go.                                  *
  *                                                        *
- * LastModified: Jun 3, 2015                              *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -22,6 +22,7 @@ package hprose
 import (
 	"container/list"
 	"errors"
+	"io"
 	"math"
 	"math/big"
 	"reflect"
@@ -56,6 +57,10 @@ const timeStringFormat = "2006-01-02 15:04:05.999999999 -0700 MST"
 
 var timeZero = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
 
+var durationType = reflect.TypeOf(time.Duration(0))
+var bigRatType = reflect.TypeOf(big.Rat{})
+var bigFloatType = reflect.TypeOf(big.Float{})
+var unmarshalerType = reflect.TypeOf((*HproseUnmarshaler)(nil)).Elem()
 var soMapType = reflect.TypeOf(map[string]interface{}(nil))
 var ooMapType = reflect.TypeOf(map[interface{}]interface{}(nil))
 
@@ -112,12 +117,31 @@ func (r *realReaderRefer) resetRef() {
 	}
 }
 
+// HproseUnmarshaler is the interface implemented by types that
+// can unserialize themselves from hprose.
+//
+// The UnmarshalHprose method should read the null value itself when
+// the marshaled value may be null.
+type HproseUnmarshaler interface {
+	UnmarshalHprose(reader *Reader) error
+}
+
+// ObjectReader is the interface implemented by the structs which have the
+// decoder generated by hprose-gen -codec.
+//
+// ReadFrom only reads the field values named by reader.Fields(), the class,
+// the reference and the object tags are read by Reader.
+type ObjectReader interface {
+	ReadFrom(reader *Reader) error
+}
+
 // Reader is a fine-grained operation struct for Hprose unserialization
 // when JSONCompatible is true, the Map data will unserialize to map[string]interface as the default type
 type Reader struct {
 	*RawReader
 	classref  []interface{}
 	fieldsref [][]string
+	fields    []string
 	readerRefer
 	JSONCompatible bool
 }
@@ -156,6 +180,9 @@ func (r *Reader) CheckTags(expectTags []byte) (tag byte, err error) {
 
 // Unserialize a data from stream
 func (r *Reader) Unserialize(p interface{}) (err error) {
+	if r.hasConverter(p) {
+		return r.ReadValue(reflect.ValueOf(p).Elem())
+	}
 	switch p := p.(type) {
 	case nil:
 		return errors.New("argument p must be non-null pointer")
@@ -416,6 +443,8 @@ func (r *Reader) Unserialize(p interface{}) (err error) {
 			err = nil
 		}
 		return err
+	case HproseUnmarshaler:
+		return p.UnmarshalHprose(r)
 	default:
 		v, err := r.checkPointer(p)
 		if err == nil {
@@ -1178,6 +1207,84 @@ func (r *Reader) ReadFloat64WithoutTag() (float64, error) {
 	return f, nil
 }
 
+// ReadComplex64 from stream
+func (r *Reader) ReadComplex64() (complex64, error) {
+	c, err := r.ReadComplex128()
+	return complex64(c), err
+}
+
+// ReadComplex128 from stream, the number is read as the real part,
+// and the list [real, imag] is read as the complex
+func (r *Reader) ReadComplex128() (complex128, error) {
+	x, err := r.readInterface()
+	if err != nil {
+		return 0, err
+	}
+	switch x := x.(type) {
+	case nil:
+		return 0, ErrNil
+	case string:
+		return strconv.ParseComplex(x, 128)
+	case *[]interface{}:
+		if len(*x) == 2 {
+			re, ok1 := toFloat64((*x)[0])
+			im, ok2 := toFloat64((*x)[1])
+			if ok1 && ok2 {
+				return complex(re, im), nil
+			}
+		}
+	default:
+		if f, ok := toFloat64(x); ok {
+			return complex(f, 0), nil
+		}
+	}
+	return 0, errors.New("cannot convert type " +
+		reflect.TypeOf(x).String() + " to type complex128")
+}
+
+// ReadBigRat from stream, the number and the string like "a/b" can be read
+func (r *Reader) ReadBigRat() (*big.Rat, error) {
+	str, err := r.readNumeric("big.Rat")
+	if err != nil {
+		return new(big.Rat), err
+	}
+	if x, ok := new(big.Rat).SetString(str); ok {
+		return x, nil
+	}
+	return nil, errors.New("cannot convert " + str + " to type big.Rat")
+}
+
+// ReadBigFloat from stream, the precision is enough to hold all the digits,
+// you can call SetPrec to round it to the precision you want
+func (r *Reader) ReadBigFloat() (*big.Float, error) {
+	str, err := r.readNumeric("big.Float")
+	if err != nil {
+		return new(big.Float), err
+	}
+	prec := uint(len(str)) * 4
+	if prec < 64 {
+		prec = 64
+	}
+	x, _, err := big.ParseFloat(str, 10, prec, big.ToNearestEven)
+	return x, err
+}
+
+// ReadDuration from stream, the number is read as nanoseconds,
+// and the string like "1h2m3s" is read by time.ParseDuration
+func (r *Reader) ReadDuration() (time.Duration, error) {
+	str, err := r.readNumeric("time.Duration")
+	if err != nil {
+		return 0, err
+	}
+	if i, err := strconv.ParseInt(str, 10, 64); err == nil {
+		return time.Duration(i), nil
+	}
+	if f, err := strconv.ParseFloat(str, 64); err == nil {
+		return time.Duration(f), nil
+	}
+	return time.ParseDuration(str)
+}
+
 // ReadBool from stream
 func (r *Reader) ReadBool() (bool, error) {
 	s := r.Stream
@@ -1586,6 +1693,23 @@ func (r *Reader) ReadArray(a []reflect.Value) error {
 	return r.CheckTag(TagClosebrace)
 }
 
+// ReadListHeader reads the tag and the count of list, the count elements
+// and ReadListFooter must follow it. It's used by the generated code.
+func (r *Reader) ReadListHeader() (count int, err error) {
+	if err = r.CheckTag(TagList); err != nil {
+		return 0, err
+	}
+	if count, err = r.ReadInteger(TagOpenbrace); err == nil {
+		r.setRef(&count)
+	}
+	return count, err
+}
+
+// ReadListFooter reads the end of list
+func (r *Reader) ReadListFooter() error {
+	return r.CheckTag(TagClosebrace)
+}
+
 // ReadSlice from stream
 func (r *Reader) ReadSlice(p interface{}) error {
 	v, err := r.checkPointer(p)
@@ -1640,6 +1764,12 @@ func (r *Reader) ReadObjectWithoutTag(p interface{}) error {
 	return err
 }
 
+// Fields returns the field names of the object which is read by
+// ObjectReader.ReadFrom, it must be called before reading any field value.
+func (r *Reader) Fields() []string {
+	return r.fields
+}
+
 // Reset the serialize reference count
 func (r *Reader) Reset() {
 	if r.classref != nil {
@@ -1652,8 +1782,32 @@ func (r *Reader) Reset() {
 // ReadValue from stream
 func (r *Reader) ReadValue(v reflect.Value) error {
 	t := v.Type()
+	if t.Kind() == reflect.Ptr && t.Implements(unmarshalerType) {
+		if r.readNull() {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return v.Interface().(HproseUnmarshaler).UnmarshalHprose(r)
+	}
+	if v.CanAddr() && reflect.PtrTo(t).Implements(unmarshalerType) {
+		return v.Addr().Interface().(HproseUnmarshaler).UnmarshalHprose(r)
+	}
+	if c := ConverterManager.getConverter(t); c != nil {
+		return r.readConverted(v, c)
+	}
+	if t.Kind() == reflect.Ptr {
+		if c := ConverterManager.getConverter(t.Elem()); c != nil {
+			return r.readConverted(v, c)
+		}
+	}
 	switch t.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == durationType {
+			return r.readDuration(v)
+		}
 		return r.readInt64(v)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return r.readUint64(v)
@@ -1663,6 +1817,8 @@ func (r *Reader) ReadValue(v reflect.Value) error {
 		return r.readFloat32(v)
 	case reflect.Float64:
 		return r.readFloat64(v)
+	case reflect.Complex64, reflect.Complex128:
+		return r.readComplex(v)
 	case reflect.String:
 		return r.readString(v)
 	case reflect.Slice:
@@ -1676,6 +1832,12 @@ func (r *Reader) ReadValue(v reflect.Value) error {
 	case reflect.Map:
 		return r.readMap(v)
 	case reflect.Struct:
+		switch t {
+		case bigRatType:
+			return r.readBigRat(v)
+		case bigFloatType:
+			return r.readBigFloat(v)
+		}
 		switch t.Name() {
 		case "Time":
 			return r.readDateTime(v)
@@ -1701,6 +1863,9 @@ func (r *Reader) ReadValue(v reflect.Value) error {
 	case reflect.Ptr:
 		switch t := t.Elem(); t.Kind() {
 		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if t == durationType {
+				return r.readDurationPointer(v)
+			}
 			return r.readInt64Pointer(v)
 		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 			return r.readUint64Pointer(v)
@@ -1710,6 +1875,8 @@ func (r *Reader) ReadValue(v reflect.Value) error {
 			return r.readFloat32Pointer(v)
 		case reflect.Float64:
 			return r.readFloat64Pointer(v)
+		case reflect.Complex64, reflect.Complex128:
+			return r.readComplexPointer(v)
 		case reflect.String:
 			return r.readStringPointer(v)
 		case reflect.Slice:
@@ -1723,6 +1890,12 @@ func (r *Reader) ReadValue(v reflect.Value) error {
 		case reflect.Map:
 			return r.readMap(v)
 		case reflect.Struct:
+			switch t {
+			case bigRatType:
+				return r.readBigRatPointer(v)
+			case bigFloatType:
+				return r.readBigFloatPointer(v)
+			}
 			switch t.Name() {
 			case "Time":
 				return r.readDateTimePointer(v)
@@ -1755,6 +1928,63 @@ func (r *Reader) ReadValue(v reflect.Value) error {
 
 // private methods
 
+// hasConverter reports whether p points to a value that a registered
+// converter reads, so that the converter takes precedence over the fast paths.
+func (r *Reader) hasConverter(p interface{}) bool {
+	t := reflect.TypeOf(p)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return false
+	}
+	t = t.Elem()
+	if ConverterManager.getConverter(t) != nil {
+		return true
+	}
+	return t.Kind() == reflect.Ptr && ConverterManager.getConverter(t.Elem()) != nil
+}
+
+// readNull consumes the next tag and returns true if it is TagNull. Any other
+// tag is left in the stream when the stream can unread it.
+func (r *Reader) readNull() bool {
+	s, ok := r.Stream.(io.ByteScanner)
+	if !ok {
+		return false
+	}
+	tag, err := s.ReadByte()
+	if err != nil {
+		return false
+	}
+	if tag == TagNull {
+		return true
+	}
+	s.UnreadByte()
+	return false
+}
+
+func (r *Reader) readConverted(v reflect.Value, c *typeConverter) error {
+	x, err := c.decode(r)
+	if err == ErrNil || err == nil && x == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	rx := reflect.ValueOf(x)
+	t := v.Type()
+	if rx.Type() != t && t.Kind() == reflect.Ptr && rx.Type() == t.Elem() {
+		p := reflect.New(t.Elem())
+		p.Elem().Set(rx)
+		rx = p
+	} else if rx.Type() != t && rx.Kind() == reflect.Ptr && rx.Type().Elem() == t {
+		rx = rx.Elem()
+	}
+	if !rx.Type().AssignableTo(t) {
+		return errors.New("cannot convert " + rx.Type().String() + " to type " + t.String())
+	}
+	v.Set(rx)
+	return nil
+}
+
 func (r *Reader) checkPointer(p interface{}) (v reflect.Value, err error) {
 	v = reflect.ValueOf(p)
 	if v.Kind() != reflect.Ptr {
@@ -1996,6 +2226,48 @@ func (r *Reader) readIntAsFloat32(tag byte) (float32, error) {
 	return float32(f), err
 }
 
+// readNumeric reads the number or the string as text,
+// so the digits of long and double are not lost.
+func (r *Reader) readNumeric(dst string) (string, error) {
+	s := r.Stream
+	tag, err := s.ReadByte()
+	if err == nil {
+		switch tag {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			return string(tag), nil
+		case TagInteger, TagLong, TagDouble:
+			return r.readUntil(TagSemicolon)
+		case TagNull:
+			return "0", ErrNil
+		case TagEmpty, TagFalse:
+			return "0", nil
+		case TagTrue:
+			return "1", nil
+		case TagInfinity:
+			var f float64
+			if f, err = r.readInfinity(); err == nil {
+				return strconv.FormatFloat(f, 'g', -1, 64), nil
+			}
+		case TagUTF8Char:
+			return r.readUTF8String(1)
+		case TagString:
+			return r.ReadStringWithoutTag()
+		case TagRef:
+			var ref interface{}
+			if ref, err = r.readRef(r.ReadInteger(TagSemicolon)); err == nil {
+				if ref, ok := ref.(string); ok {
+					return ref, nil
+				}
+				return "", errors.New("cannot convert type " +
+					reflect.TypeOf(ref).String() + " to type " + dst)
+			}
+		default:
+			return "", convertError(tag, dst)
+		}
+	}
+	return "", err
+}
+
 func (r *Reader) readInfinity() (float64, error) {
 	if sign, err := r.Stream.ReadByte(); err == nil {
 		switch sign {
@@ -2146,6 +2418,42 @@ func (r *Reader) readFloat64(v reflect.Value) error {
 	return err
 }
 
+func (r *Reader) readComplex(v reflect.Value) error {
+	x, err := r.ReadComplex128()
+	if err == nil || err == ErrNil {
+		v.SetComplex(x)
+		return nil
+	}
+	return err
+}
+
+func (r *Reader) readBigRat(v reflect.Value) error {
+	x, err := r.ReadBigRat()
+	if err == nil || err == ErrNil {
+		v.Set(reflect.ValueOf(x).Elem())
+		return nil
+	}
+	return err
+}
+
+func (r *Reader) readBigFloat(v reflect.Value) error {
+	x, err := r.ReadBigFloat()
+	if err == nil || err == ErrNil {
+		v.Set(reflect.ValueOf(x).Elem())
+		return nil
+	}
+	return err
+}
+
+func (r *Reader) readDuration(v reflect.Value) error {
+	x, err := r.ReadDuration()
+	if err == nil || err == ErrNil {
+		v.SetInt(int64(x))
+		return nil
+	}
+	return err
+}
+
 func (r *Reader) readBigInt(v reflect.Value) error {
 	x, err := r.ReadBigInt()
 	if err == nil || err == ErrNil {
@@ -2623,26 +2931,13 @@ func (r *Reader) readObjectWithoutTag(v reflect.Value) error {
 	r.setRef(objPointer.Interface())
 	obj := objPointer.Elem()
 	fields := r.fieldsref[index]
-	indexMap := getIndexCache(class)
-	count := len(fields)
-	for i := 0; i < count; i++ {
-		if index, ok := indexMap[strings.ToLower(fields[i])]; ok {
-			f := obj.Field(index[0])
-			n := len(index)
-			for j := 1; j < n; j++ {
-				if f.Kind() == reflect.Ptr {
-					f.Set(reflect.New(f.Type().Elem()))
-					f = f.Elem()
-				}
-				f = f.Field(index[j])
-			}
-			err = r.ReadValue(f)
-		} else {
-			_, err = r.readInterface()
-		}
-		if err != nil {
+	if o, ok := objPointer.Interface().(ObjectReader); ok {
+		r.fields = fields
+		if err = o.ReadFrom(r); err != nil {
 			return err
 		}
+	} else if err = r.readFields(obj, class, fields); err != nil {
+		return err
 	}
 	if err = r.CheckTag(TagClosebrace); err == nil {
 		switch kind {
@@ -2664,6 +2959,31 @@ func (r *Reader) readObjectWithoutTag(v reflect.Value) error {
 	return err
 }
 
+func (r *Reader) readFields(obj reflect.Value, class reflect.Type, fields []string) (err error) {
+	indexMap := getIndexCache(class)
+	count := len(fields)
+	for i := 0; i < count; i++ {
+		if index, ok := indexMap[strings.ToLower(fields[i])]; ok {
+			f := obj.Field(index[0])
+			n := len(index)
+			for j := 1; j < n; j++ {
+				if f.Kind() == reflect.Ptr {
+					f.Set(reflect.New(f.Type().Elem()))
+					f = f.Elem()
+				}
+				f = f.Field(index[j])
+			}
+			err = r.ReadValue(f)
+		} else {
+			_, err = r.readInterface()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *Reader) readClass() error {
 	className, err := r.readStringWithoutTag()
 	if err != nil {
@@ -2749,6 +3069,30 @@ func (r *Reader) readBigIntPointer(v reflect.Value) error {
 	return r.readPointer(v, r.getBigInt, r.setBigInt)
 }
 
+func (r *Reader) getComplex() (interface{}, error)          { return r.ReadComplex128() }
+func (r *Reader) setComplex(v reflect.Value, x interface{}) { v.SetComplex(x.(complex128)) }
+func (r *Reader) readComplexPointer(v reflect.Value) error {
+	return r.readPointer(v, r.getComplex, r.setComplex)
+}
+
+func (r *Reader) getBigRat() (interface{}, error)          { return r.ReadBigRat() }
+func (r *Reader) setBigRat(v reflect.Value, x interface{}) { v.Set(reflect.ValueOf(x)) }
+func (r *Reader) readBigRatPointer(v reflect.Value) error {
+	return r.readPointer(v, r.getBigRat, r.setBigRat)
+}
+
+func (r *Reader) getBigFloat() (interface{}, error)          { return r.ReadBigFloat() }
+func (r *Reader) setBigFloat(v reflect.Value, x interface{}) { v.Set(reflect.ValueOf(x)) }
+func (r *Reader) readBigFloatPointer(v reflect.Value) error {
+	return r.readPointer(v, r.getBigFloat, r.setBigFloat)
+}
+
+func (r *Reader) getDuration() (interface{}, error)          { return r.ReadDuration() }
+func (r *Reader) setDuration(v reflect.Value, x interface{}) { v.SetInt(int64(x.(time.Duration))) }
+func (r *Reader) readDurationPointer(v reflect.Value) error {
+	return r.readPointer(v, r.getDuration, r.setDuration)
+}
+
 func (r *Reader) getDateTime() (interface{}, error)          { return r.ReadDateTime() }
 func (r *Reader) setDateTime(v reflect.Value, x interface{}) { v.Set(reflect.ValueOf(x)) }
 func (r *Reader) readDateTimePointer(v reflect.Value) error {
@@ -2781,6 +3125,27 @@ func (r *Reader) readListPointer(v reflect.Value) error {
 
 // private functions
 
+func toFloat64(x interface{}) (float64, bool) {
+	switch x := x.(type) {
+	case int:
+		return float64(x), true
+	case float64:
+		return x, true
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(x).Float64()
+		return f, true
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		return f, err == nil
+	case bool:
+		if x {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
 func convertError(tag byte, dst string) error {
 	src, err := tagToString(tag)
 	if err == nil {
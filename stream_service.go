@@ -12,7 +12,7 @@
  *                                                        *
  * hprose stream service for Go.                          *
  *                                                        *
- * LastModified: May 25, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Authors: Ma Bingyao <andot@hprose.com>                 *
  *          Ore_Ash <nanohugh@gmail.com>                  *
  *                                                        *
@@ -21,32 +21,51 @@
 package hprose
 
 import (
+	"bufio"
 	"net"
+	"sync"
 	"time"
 )
 
 // StreamService is the base service for TcpService and UnixService
 type StreamService struct {
 	*BaseService
-	timeout      interface{}
-	readTimeout  interface{}
-	readBuffer   interface{}
-	writeTimeout interface{}
-	writeBuffer  interface{}
+	timeout               interface{}
+	readTimeout           interface{}
+	readBuffer            interface{}
+	writeTimeout          interface{}
+	writeBuffer           interface{}
+	maxConcurrentRequests int
 }
 
 // StreamContext is the hprose stream context for service
 type StreamContext struct {
 	*BaseContext
 	net.Conn
+	// ConnData is shared by the requests over the connection
+	ConnData *sync.Map
 }
 
 func newStreamService() (service *StreamService) {
 	service = new(StreamService)
 	service.BaseService = NewBaseService()
+	service.maxConcurrentRequests = 10
 	return
 }
 
+// MaxConcurrentRequests returns the max concurrent full duplex requests
+// of each connection
+func (service *StreamService) MaxConcurrentRequests() int {
+	return service.maxConcurrentRequests
+}
+
+// SetMaxConcurrentRequests sets the max concurrent full duplex requests of
+// each connection, the connection isn't read until a request is done when
+// the limit is reached
+func (service *StreamService) SetMaxConcurrentRequests(value int) {
+	service.maxConcurrentRequests = value
+}
+
 // SetTimeout for stream service
 func (service *StreamService) SetTimeout(d time.Duration) {
 	service.timeout = d
@@ -73,23 +92,52 @@ func (service *StreamService) SetWriteBuffer(bytes int) {
 }
 
 func (service *StreamService) serve(conn net.Conn) {
-	var data []byte
-	var err error
+	reader := bufio.NewReader(conn)
+	mutex := sync.Mutex{}
+	connData := new(sync.Map)
+	limit := service.maxConcurrentRequests
+	if limit < 1 {
+		limit = 1
+	}
+	semaphore := make(chan struct{}, limit)
+	send := func(id uint32, data []byte, duplex bool) (err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if service.writeTimeout != nil {
+			if err = conn.SetWriteDeadline(time.Now().Add(service.writeTimeout.(time.Duration))); err != nil {
+				return err
+			}
+		}
+		if duplex {
+			return sendFrameOverStream(conn, id, data)
+		}
+		return sendDataOverStream(conn, data)
+	}
 	for {
+		var id uint32
+		var data []byte
+		var duplex bool
+		var err error
 		if service.readTimeout != nil {
 			err = conn.SetReadDeadline(time.Now().Add(service.readTimeout.(time.Duration)))
 		}
 		if err == nil {
-			data, err = receiveDataOverStream(conn)
+			id, data, duplex, err = receiveFrameOverStream(reader)
 		}
 		if err == nil {
-			data = service.Handle(data, &StreamContext{BaseContext: NewBaseContext(), Conn: conn})
-			if service.writeTimeout != nil {
-				err = conn.SetWriteDeadline(time.Now().Add(service.writeTimeout.(time.Duration)))
-			}
-			if err == nil {
-				err = sendDataOverStream(conn, data)
+			context := &StreamContext{BaseContext: NewBaseContext(), Conn: conn, ConnData: connData}
+			if duplex {
+				semaphore <- struct{}{}
+				go func(id uint32, data []byte, context *StreamContext) {
+					defer func() { <-semaphore }()
+					if err := send(id, service.Handle(data, context), true); err != nil {
+						service.fireErrorEvent(err, context)
+						conn.Close()
+					}
+				}(id, data, context)
+				continue
 			}
+			err = send(id, service.Handle(data, context), false)
 		}
 		if err != nil {
 			conn.Close()
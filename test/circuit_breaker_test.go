@@ -0,0 +1,126 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/circuit_breaker_test.go                         *
+ *                                                        *
+ * hprose circuit breaker Test for Go.                    *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../hprose"
+)
+
+func TestCircuitBreakerState(t *testing.T) {
+	breaker := &hprose.CircuitBreaker{FailureRate: 0.5, MinRequests: 4, Window: time.Minute, OpenTime: 50 * time.Millisecond}
+	failure := &hprose.HttpStatusError{StatusCode: 503}
+	for _, err := range []error{nil, failure, errors.New("not a failure")} {
+		if e := breaker.Allow("a"); e != nil {
+			t.Error(e)
+		}
+		breaker.Report("a", err)
+	}
+	if state := breaker.State("a"); state != hprose.CircuitClosed {
+		t.Error(state)
+	}
+	breaker.Allow("a")
+	breaker.Report("a", failure)
+	if state := breaker.State("a"); state != hprose.CircuitOpen {
+		t.Error(state)
+	}
+	if err, ok := breaker.Allow("a").(*hprose.CircuitOpenError); !ok || err.Key != "a" {
+		t.Error(err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := breaker.Allow("a"); err != nil || breaker.State("a") != hprose.CircuitHalfOpen {
+		t.Error(err, breaker.State("a"))
+	}
+	if _, ok := breaker.Allow("a").(*hprose.CircuitOpenError); !ok {
+		t.Error("only one probe is allowed")
+	}
+	breaker.Report("a", failure)
+	if state := breaker.State("a"); state != hprose.CircuitOpen {
+		t.Error(state)
+	}
+	time.Sleep(60 * time.Millisecond)
+	breaker.Allow("a")
+	breaker.Report("a", nil)
+	if states := breaker.States(); len(states) != 1 || states["a"] != hprose.CircuitClosed || states["a"].String() != "Closed" {
+		t.Error(states)
+	}
+}
+
+func TestCircuitBreakerClient(t *testing.T) {
+	a := newTestClusterServer("a", nil)
+	defer a.Close()
+	client := hprose.NewHttpClient(a.URL)
+	breaker := hprose.NewCircuitBreaker()
+	breaker.MinRequests = 2
+	breaker.OpenTime = 50 * time.Millisecond
+	breaker.PerMethod = true
+	client.CircuitBreaker = breaker
+	key := breaker.Key(client.Uri(), "id")
+	atomic.StoreInt32(&a.down, 1)
+	var id string
+	for i := 0; i < 2; i++ {
+		if err, ok := (<-client.Invoke("id", nil, nil, &id)).(*hprose.HttpStatusError); !ok {
+			t.Error(err)
+		}
+	}
+	atomic.StoreInt32(&a.down, 0)
+	if err, ok := (<-client.Invoke("id", nil, nil, &id)).(*hprose.CircuitOpenError); !ok || err.Key != key {
+		t.Error(err)
+	}
+	if state := breaker.State(client.Uri()); state != hprose.CircuitClosed {
+		t.Error(state)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := <-client.Invoke("id", nil, nil, &id); err != nil || id != "a" || breaker.State(key) != hprose.CircuitClosed {
+		t.Error(id, err, breaker.State(key))
+	}
+}
+
+func TestCircuitBreakerClusterClient(t *testing.T) {
+	a := newTestClusterServer("a", nil)
+	defer a.Close()
+	b := newTestClusterServer("b", nil)
+	defer b.Close()
+	client := hprose.NewClusterClient([]string{a.URL, b.URL}, hprose.BalanceRoundRobin)
+	defer client.Close()
+	client.MaxFailures = 100
+	client.CircuitBreaker = &hprose.CircuitBreaker{FailureRate: 0.5, MinRequests: 1, Window: time.Minute, OpenTime: time.Minute}
+	atomic.StoreInt32(&b.down, 1)
+	var id string
+	if err := <-client.Invoke("id", nil, nil, &id); err != nil || id != "a" {
+		t.Error(id, err)
+	}
+	if err := <-client.Invoke("id", nil, nil, &id); err == nil {
+		t.Error(id)
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-client.Invoke("id", nil, nil, &id); err != nil || id != "a" {
+			t.Error(id, err)
+		}
+	}
+	uris := client.Uris()
+	if state := client.CircuitBreaker.State(uris[1]); state != hprose.CircuitOpen {
+		t.Error(state)
+	}
+}
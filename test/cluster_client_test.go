@@ -0,0 +1,154 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/cluster_client_test.go                          *
+ *                                                        *
+ * hprose cluster client Test for Go.                     *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../hprose"
+)
+
+type testClusterServer struct {
+	*httptest.Server
+	down int32
+}
+
+func newTestClusterServer(id string, wait chan struct{}) *testClusterServer {
+	service := hprose.NewHttpService()
+	service.AddFunction("id", func() string { return id })
+	service.AddFunction("wait", func() string {
+		<-wait
+		return id
+	})
+	server := new(testClusterServer)
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&server.down) != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		service.ServeHTTP(w, r)
+	}))
+	return server
+}
+
+func clusterIds(t *testing.T, client hprose.Client, ctx context.Context, n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		if err := <-client.InvokeContext(ctx, "id", nil, nil, &ids[i]); err != nil {
+			t.Error(err)
+		}
+	}
+	return ids
+}
+
+func TestClusterClientBalance(t *testing.T) {
+	wait := make(chan struct{})
+	a := newTestClusterServer("a", wait)
+	defer a.Close()
+	b := newTestClusterServer("b", wait)
+	defer b.Close()
+	tcp := hprose.NewTcpServer("")
+	tcp.AddFunction("id", func() string { return "c" })
+	tcp.Handle()
+	defer tcp.Stop()
+	uris := []string{a.URL, b.URL, tcp.URL}
+	client := hprose.NewClusterClient(uris, hprose.BalanceRoundRobin)
+	defer client.Close()
+	if !reflect.DeepEqual(client.Uris(), uris) {
+		t.Error(client.Uris())
+	}
+	if ids := clusterIds(t, client, context.Background(), 6); !reflect.DeepEqual(ids, []string{"a", "b", "c", "a", "b", "c"}) {
+		t.Error(ids)
+	}
+	client = hprose.NewClusterClient(uris, hprose.BalanceRandom)
+	for _, id := range clusterIds(t, client, context.Background(), 10) {
+		if id != "a" && id != "b" && id != "c" {
+			t.Error(id)
+		}
+	}
+	client.Close()
+	client = hprose.NewClusterClient(uris, hprose.BalanceConsistentHash)
+	for _, key := range []string{"user:1", "user:2", "user:3"} {
+		ids := clusterIds(t, client, hprose.WithHashKey(context.Background(), key), 5)
+		for _, id := range ids {
+			if id != ids[0] {
+				t.Error(key, ids)
+			}
+		}
+	}
+	client.Close()
+	client = hprose.NewClusterClient(uris[:2], hprose.BalanceLeastPending)
+	var waiting <-chan string
+	errChan := client.Invoke("wait", nil, nil, &waiting)
+	time.Sleep(50 * time.Millisecond)
+	ids := clusterIds(t, client, context.Background(), 4)
+	close(wait)
+	busy := <-waiting
+	if err := <-errChan; err != nil {
+		t.Error(err)
+	}
+	for _, id := range ids {
+		if id == busy {
+			t.Error(busy, ids)
+		}
+	}
+	client.Close()
+}
+
+func TestClusterClientEjection(t *testing.T) {
+	a := newTestClusterServer("a", nil)
+	defer a.Close()
+	b := newTestClusterServer("b", nil)
+	defer b.Close()
+	client := hprose.NewClusterClient([]string{a.URL, b.URL}, hprose.BalanceRoundRobin)
+	defer client.Close()
+	client.MaxFailures = 1
+	client.EjectTime = 100 * time.Millisecond
+	atomic.StoreInt32(&b.down, 1)
+	var id string
+	if err := <-client.Invoke("id", nil, nil, &id); err != nil || id != "a" {
+		t.Error(id, err)
+	}
+	if err := <-client.Invoke("id", nil, nil, &id); err == nil {
+		t.Error(id)
+	}
+	if ids := clusterIds(t, client, context.Background(), 4); !reflect.DeepEqual(ids, []string{"a", "a", "a", "a"}) {
+		t.Error(ids)
+	}
+	atomic.StoreInt32(&b.down, 0)
+	time.Sleep(150 * time.Millisecond)
+	if ids := clusterIds(t, client, context.Background(), 4); !reflect.DeepEqual(ids, []string{"b", "a", "b", "a"}) &&
+		!reflect.DeepEqual(ids, []string{"a", "b", "a", "b"}) {
+		t.Error(ids)
+	}
+	atomic.StoreInt32(&a.down, 1)
+	client.RetryPolicy = &hprose.RetryPolicy{MaxAttempts: 2}
+	for i := 0; i < 4; i++ {
+		if err := <-client.Invoke("id", nil, &hprose.InvokeOptions{Idempotent: true}, &id); err != nil || id != "b" {
+			t.Error(id, err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/compression_filter_test.go                      *
+ *                                                        *
+ * hprose compression filter Test for Go.                 *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"../hprose"
+)
+
+// spyFilter records the data on the wire
+type spyFilter struct {
+	input, output []byte
+}
+
+func (filter *spyFilter) InputFilter(data []byte, context hprose.Context) []byte {
+	filter.input = data
+	return data
+}
+
+func (filter *spyFilter) OutputFilter(data []byte, context hprose.Context) []byte {
+	filter.output = data
+	return data
+}
+
+func TestCompressionFilter(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("hello", hello)
+	server.AddFilter(hprose.NewCompressionFilter(hprose.CompressionGzip))
+	server.Handle()
+	defer server.Stop()
+	long := strings.Repeat("hprose", 1000)
+	for _, algorithm := range []byte{hprose.CompressionGzip, hprose.CompressionDeflate} {
+		client := hprose.NewClient(server.URL)
+		spy := new(spyFilter)
+		client.AddFilter(hprose.NewCompressionFilter(algorithm))
+		client.AddFilter(spy)
+		var s string
+		if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello world!" {
+			t.Error(s, err)
+		}
+		if spy.output[0] != 0xc1 || spy.output[1] != algorithm || spy.output[2] != 0 ||
+			spy.input[0] != 0xc1 || spy.input[1] != algorithm || spy.input[2] != 0 {
+			t.Errorf("% x, % x", spy.output[:3], spy.input[:3])
+		}
+		if err := <-client.Invoke("hello", []interface{}{long}, nil, &s); err != nil || s != "Hello "+long+"!" {
+			t.Error(err)
+		}
+		if spy.output[1] != algorithm || spy.output[2] != 1 || len(spy.output) > 1000 ||
+			spy.input[1] != algorithm || spy.input[2] != 1 || len(spy.input) > 1000 {
+			t.Errorf("% x, % x", spy.output[:3], spy.input[:3])
+		}
+		client.Close()
+	}
+	client := hprose.NewClient(server.URL)
+	defer client.Close()
+	spy := new(spyFilter)
+	client.AddFilter(spy)
+	var s string
+	if err := <-client.Invoke("hello", []interface{}{long}, nil, &s); err != nil || s != "Hello "+long+"!" {
+		t.Error(err)
+	}
+	if spy.input[0] != 'R' {
+		t.Errorf("% x", spy.input[:3])
+	}
+}
+
+func compressionInputError(filter *hprose.CompressionFilter, data []byte) (err error) {
+	defer func() {
+		err, _ = recover().(error)
+	}()
+	filter.InputFilter(data, hprose.NewBaseContext())
+	return nil
+}
+
+func TestCompressionFilterInputError(t *testing.T) {
+	filter := hprose.NewCompressionFilter(hprose.CompressionGzip)
+	filter.Threshold = 0
+	data := filter.OutputFilter([]byte(strings.Repeat("hprose", 100)), new(hprose.ClientContext))
+	if data[2] != 1 {
+		t.Fatalf("% x", data[:3])
+	}
+	if err := compressionInputError(filter, data); err != nil {
+		t.Error(err)
+	}
+	if err := compressionInputError(filter, []byte{0xc1, 0xff, 1, 'x'}); !errors.Is(err, hprose.ErrCompressionAlgorithm) {
+		t.Error(err)
+	}
+	if err := compressionInputError(filter, data[:len(data)-4]); !errors.Is(err, hprose.ErrCompressionData) {
+		t.Error(err)
+	}
+	filter.MaxSize = 100
+	if err := compressionInputError(filter, data); !errors.Is(err, hprose.ErrCompressionTooLarge) {
+		t.Error(err)
+	}
+}
+
+func TestCompressionFilterDefaultMaxSize(t *testing.T) {
+	filter := hprose.NewCompressionFilter(hprose.CompressionGzip)
+	if filter.MaxSize != 32<<20 {
+		t.Error(filter.MaxSize)
+	}
+	data := filter.OutputFilter(make([]byte, filter.MaxSize+1), new(hprose.ClientContext))
+	if err := compressionInputError(filter, data); !errors.Is(err, hprose.ErrCompressionTooLarge) {
+		t.Error(err)
+	}
+	filter.MaxSize = 0
+	if err := compressionInputError(filter, data); err != nil {
+		t.Error(err)
+	}
+}
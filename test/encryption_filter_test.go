@@ -0,0 +1,141 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/encryption_filter_test.go                       *
+ *                                                        *
+ * hprose encryption filter Test for Go.                  *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"../hprose"
+)
+
+// tamperFilter replays or tampers the requests on the wire
+type tamperFilter struct {
+	last    []byte
+	replay  bool
+	corrupt bool
+}
+
+func (filter *tamperFilter) InputFilter(data []byte, context hprose.Context) []byte {
+	return data
+}
+
+func (filter *tamperFilter) OutputFilter(data []byte, context hprose.Context) []byte {
+	if filter.replay {
+		filter.replay = false
+		return filter.last
+	}
+	if filter.corrupt {
+		filter.corrupt = false
+		data = append([]byte{}, data...)
+		data[len(data)-1] ^= 1
+	}
+	filter.last = data
+	return data
+}
+
+var testPSK = []byte("hprose pre-shared key")
+
+func TestEncryptionFilter(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("hello", hello)
+	server.AddFilter(hprose.NewEncryptionServiceFilter(testPSK))
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewClient(server.URL).(*hprose.TcpClient)
+	defer client.Close()
+	client.SetFullDuplex(true)
+	spy := new(spyFilter)
+	tamper := new(tamperFilter)
+	client.AddFilter(hprose.NewEncryptionClientFilter(hprose.CipherAESGCM, testPSK))
+	client.AddFilter(tamper)
+	client.AddFilter(spy)
+	var s string
+	for i := 0; i < 3; i++ {
+		if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello world!" {
+			t.Error(s, err)
+		}
+	}
+	if spy.output[0] != 0xc2 || spy.output[1] != 'D' || bytes.Contains(spy.output, []byte("world")) ||
+		spy.input[0] != 0xc2 || spy.input[1] != 'D' || bytes.Contains(spy.input, []byte("world")) {
+		t.Errorf("% x, % x", spy.output, spy.input)
+	}
+	tamper.replay = true
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); !errors.Is(err, hprose.ErrEncryptionReplay) {
+		t.Error(err)
+	}
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello world!" {
+		t.Error(s, err)
+	}
+	tamper.corrupt = true
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); !errors.Is(err, hprose.ErrEncryptionKeys) {
+		t.Error(err)
+	}
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello world!" {
+		t.Error(s, err)
+	}
+}
+
+func TestEncryptionFilterReconnect(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("hello", hello)
+	server.AddFilter(hprose.NewEncryptionServiceFilter(testPSK))
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewClient(server.URL).(*hprose.TcpClient)
+	defer client.Close()
+	client.SetFullDuplex(true)
+	client.AddFilter(hprose.NewEncryptionClientFilter(hprose.CipherAESGCM, testPSK))
+	var s string
+	for i := 0; i < 3; i++ {
+		if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello world!" {
+			t.Error(i, s, err)
+		}
+		client.Close()
+	}
+}
+
+func TestEncryptionFilterErrors(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("hello", hello)
+	server.AddFilter(hprose.NewEncryptionServiceFilter(testPSK))
+	server.Handle()
+	defer server.Stop()
+	var s string
+	client := hprose.NewClient(server.URL)
+	var e *hprose.RemoteError
+	err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s)
+	if !errors.As(err, &e) || e.Message != hprose.ErrEncryptionRequired.Error() {
+		t.Error(err)
+	}
+	client.AddFilter(hprose.NewEncryptionClientFilter(hprose.CipherAESGCM, testPSK))
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err == nil {
+		t.Error("the half duplex client must fail")
+	}
+	client.Close()
+	client = hprose.NewClient(server.URL)
+	defer client.Close()
+	client.(*hprose.TcpClient).SetFullDuplex(true)
+	client.AddFilter(hprose.NewEncryptionClientFilter(hprose.CipherAESGCM, []byte("wrong pre-shared key")))
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); !errors.Is(err, hprose.ErrEncryptionKeys) {
+		t.Error(err)
+	}
+}
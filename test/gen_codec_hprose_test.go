@@ -0,0 +1,114 @@
+// Code generated by hprose-gen. DO NOT EDIT.
+
+package hprose_test
+
+import (
+	"strings"
+
+	"../hprose"
+)
+
+// WriteTo writes the fields of GenUser to writer
+func (v *GenUser) WriteTo(writer *hprose.Writer) (err error) {
+	if err = writer.WriteStringWithRef(v.Name); err != nil {
+		return
+	}
+	if err = writer.WriteInt64(int64(v.Age)); err != nil {
+		return
+	}
+	if err = writer.WriteStringWithRef(v.Nick); err != nil {
+		return
+	}
+	if err = writer.Serialize(v.Tags); err != nil {
+		return
+	}
+	if err = writer.Serialize(v.Birthday); err != nil {
+		return
+	}
+	if err = writer.WriteFloat64(v.Score); err != nil {
+		return
+	}
+	if err = writer.Serialize(&v.Rate); err != nil {
+		return
+	}
+	if err = writer.Serialize(v.Home); err != nil {
+		return
+	}
+	if err = writer.Serialize(v.Points); err != nil {
+		return
+	}
+	if v.Friend == nil {
+		err = writer.WriteNull()
+	} else {
+		err = writer.Serialize(v.Friend)
+	}
+	if err != nil {
+		return
+	}
+	return nil
+}
+
+// ReadFrom reads the fields of GenUser from reader
+func (v *GenUser) ReadFrom(reader *hprose.Reader) (err error) {
+	for _, name := range reader.Fields() {
+		switch strings.ToLower(name) {
+		case "name":
+			err = reader.Unserialize(&v.Name)
+		case "age":
+			err = reader.Unserialize(&v.Age)
+		case "nickname":
+			err = reader.Unserialize(&v.Nick)
+		case "tags":
+			err = reader.Unserialize(&v.Tags)
+		case "birthday":
+			err = reader.Unserialize(&v.Birthday)
+		case "score":
+			err = reader.Unserialize(&v.Score)
+		case "rate":
+			err = reader.Unserialize(&v.Rate)
+		case "home":
+			err = reader.Unserialize(&v.Home)
+		case "points":
+			err = reader.Unserialize(&v.Points)
+		case "friend":
+			err = reader.Unserialize(&v.Friend)
+		default:
+			var value interface{}
+			err = reader.Unserialize(&value)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// WriteTo writes the fields of GenPoint to writer
+func (v *GenPoint) WriteTo(writer *hprose.Writer) (err error) {
+	if err = writer.WriteInt64(int64(v.X)); err != nil {
+		return
+	}
+	if err = writer.WriteInt64(int64(v.Y)); err != nil {
+		return
+	}
+	return nil
+}
+
+// ReadFrom reads the fields of GenPoint from reader
+func (v *GenPoint) ReadFrom(reader *hprose.Reader) (err error) {
+	for _, name := range reader.Fields() {
+		switch strings.ToLower(name) {
+		case "x":
+			err = reader.Unserialize(&v.X)
+		case "y":
+			err = reader.Unserialize(&v.Y)
+		default:
+			var value interface{}
+			err = reader.Unserialize(&value)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,180 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/gen_codec_test.go                               *
+ *                                                        *
+ * hprose generated codec Test for Go.                    *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"../hprose"
+)
+
+//go:generate go run ../cmd/hprose-gen/main.go ../cmd/hprose-gen/generator.go ../cmd/hprose-gen/codec.go -codec -type=GenUser,GenPoint -tag=json -output=gen_codec_hprose_test.go -hprose=../hprose
+
+type GenPoint struct {
+	X, Y int
+}
+
+type genPercent struct {
+	value int
+}
+
+func (p *genPercent) MarshalHprose(writer *hprose.Writer) error {
+	return writer.WriteString(strconv.Itoa(p.value) + "%")
+}
+
+func (p *genPercent) UnmarshalHprose(reader *hprose.Reader) error {
+	s, err := reader.ReadString()
+	if err == nil && len(s) > 0 {
+		p.value, err = strconv.Atoi(s[:len(s)-1])
+	}
+	return err
+}
+
+type GenUser struct {
+	Name     string
+	Age      int
+	Nick     string `json:"nickname"`
+	Secret   string `json:"-"`
+	Tags     []string
+	Birthday time.Time
+	Score    float64
+	Rate     genPercent
+	Home     GenPoint
+	Points   []*GenPoint
+	Friend   *GenUser
+	Notify   chan int
+	private  int
+}
+
+type genUserPlain struct {
+	Name     string
+	Age      int
+	Nick     string `json:"nickname"`
+	Secret   string `json:"-"`
+	Tags     []string
+	Birthday time.Time
+	Score    float64
+	Rate     genPercent
+	Home     genPointPlain
+	Points   []*genPointPlain
+	Friend   *genUserPlain
+	Notify   chan int
+	private  int
+}
+
+type genPointPlain struct {
+	X, Y int
+}
+
+func init() {
+	hprose.ClassManager.Register(reflect.TypeOf(genUserPlain{}), "GenUser", "json")
+	hprose.ClassManager.Register(reflect.TypeOf(genPointPlain{}), "GenPoint", "json")
+	hprose.ClassManager.Register(reflect.TypeOf(GenUser{}), "GenUser", "json")
+	hprose.ClassManager.Register(reflect.TypeOf(GenPoint{}), "GenPoint", "json")
+}
+
+func newGenUsers() (*GenUser, *genUserPlain) {
+	birthday := time.Date(1980, 12, 1, 0, 0, 0, 0, time.UTC)
+	user := &GenUser{
+		Name: "Tom", Age: 36, Nick: "tommy", Secret: "123456",
+		Tags: []string{"a", "b"}, Birthday: birthday, Score: 98.5,
+		Rate: genPercent{50}, Home: GenPoint{1, 2},
+		Points: []*GenPoint{{3, 4}, nil}, private: 1,
+	}
+	user.Points = append(user.Points, user.Points[0])
+	user.Friend = user
+	plain := &genUserPlain{
+		Name: "Tom", Age: 36, Nick: "tommy", Secret: "123456",
+		Tags: []string{"a", "b"}, Birthday: birthday, Score: 98.5,
+		Rate: genPercent{50}, Home: genPointPlain{1, 2},
+		Points: []*genPointPlain{{3, 4}, nil}, private: 1,
+	}
+	plain.Points = append(plain.Points, plain.Points[0])
+	plain.Friend = plain
+	return user, plain
+}
+
+func TestGeneratedCodec(t *testing.T) {
+	user, plain := newGenUsers()
+	for _, simple := range []bool{false, true} {
+		if simple {
+			user.Friend, plain.Friend = nil, nil
+		}
+		b1 := new(bytes.Buffer)
+		b2 := new(bytes.Buffer)
+		w1 := hprose.NewWriter(b1, simple)
+		w2 := hprose.NewWriter(b2, simple)
+		for i := 0; i < 2; i++ {
+			if err := w1.Serialize(user); err != nil {
+				t.Fatal(err)
+			}
+			if err := w2.Serialize(plain); err != nil {
+				t.Fatal(err)
+			}
+			if err := w1.Serialize([]GenUser{*user}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w2.Serialize([]genUserPlain{*plain}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if b1.String() != b2.String() {
+			t.Fatalf("generated: %s\nreflective: %s", b1, b2)
+		}
+		reader := hprose.NewReader(bytes.NewBuffer(b1.Bytes()), simple)
+		var u1 *GenUser
+		var u2 []GenUser
+		if err := reader.Unserialize(&u1); err != nil {
+			t.Fatal(err)
+		}
+		if err := reader.Unserialize(&u2); err != nil || len(u2) != 1 {
+			t.Fatal(u2, err)
+		}
+		for _, u := range []*GenUser{u1, &u2[0]} {
+			if u.Name != "Tom" || u.Age != 36 || u.Nick != "tommy" || u.Secret != "" ||
+				len(u.Tags) != 2 || !u.Birthday.Equal(user.Birthday) || u.Score != 98.5 ||
+				u.Rate.value != 50 || u.Home != (GenPoint{1, 2}) || len(u.Points) != 3 ||
+				*u.Points[0] != (GenPoint{3, 4}) || u.Points[1] != nil {
+				t.Error(u)
+			}
+		}
+		if !simple && (u1.Friend != u1 || u1.Points[2] != u1.Points[0]) {
+			t.Error("references are not restored")
+		}
+		var p *genUserPlain
+		reader = hprose.NewReader(bytes.NewBuffer(b1.Bytes()), simple)
+		if err := reader.ReadValue(reflect.ValueOf(&p).Elem()); err == nil {
+			t.Error("GenUser should not be converted to genUserPlain")
+		}
+	}
+}
+
+func TestGeneratedCodecUnknownFields(t *testing.T) {
+	data := `c8"GenPoint"3{s1"y"s1"z"s1"X"}o0{2a1{5}3}`
+	reader := hprose.NewReader(bytes.NewBufferString(data), false)
+	var p GenPoint
+	if err := reader.Unserialize(&p); err != nil || p != (GenPoint{3, 2}) {
+		t.Error(p, err)
+	}
+}
@@ -0,0 +1,234 @@
+// Code generated by hprose-gen. DO NOT EDIT.
+
+package hprose_test
+
+import (
+	"context"
+	"time"
+
+	"../hprose"
+)
+
+// GenCalculatorOptions is the InterfaceOptions of GenCalculator
+var GenCalculatorOptions = hprose.InterfaceOptions{
+	"Greet": {Name: "hello", SimpleMode: true},
+	"Now":   {Idempotent: true},
+	"Raw":   {ResultMode: hprose.Raw},
+}
+
+// GenCalculatorStub is the struct stub of GenCalculator for UseService
+type GenCalculatorStub struct {
+	Swap  func(int, int) (int, int)
+	Sum   func(...int) (int, error)
+	Greet func(context.Context, string) (string, error) `name:"hello" simple:"true"`
+	Now   func() time.Time                              `idempotent:"true"`
+	Reset func()
+	Raw   func(string) ([]byte, error) `result:"raw"`
+	Fail  func() error
+}
+
+// GenCalculatorProxy implements GenCalculator by the remote methods of hprose.Stub
+type GenCalculatorProxy struct {
+	swapFunc  func(int, int) (int, int)
+	sumFunc   func(...int) (int, error)
+	greetFunc func(context.Context, string) (string, error)
+	nowFunc   func() time.Time
+	resetFunc func()
+	rawFunc   func(string) ([]byte, error)
+	failFunc  func() error
+}
+
+// Swap invokes the remote method Swap
+func (proxy *GenCalculatorProxy) Swap(a int, b int) (r0 int, r1 int) {
+	return proxy.swapFunc(a, b)
+}
+
+// Sum invokes the remote method Sum
+func (proxy *GenCalculatorProxy) Sum(nums ...int) (r0 int, err error) {
+	return proxy.sumFunc(nums...)
+}
+
+// Greet invokes the remote method hello
+func (proxy *GenCalculatorProxy) Greet(ctx context.Context, name string) (r0 string, err error) {
+	return proxy.greetFunc(ctx, name)
+}
+
+// Now invokes the remote method Now
+func (proxy *GenCalculatorProxy) Now() (r0 time.Time) {
+	return proxy.nowFunc()
+}
+
+// Reset invokes the remote method Reset
+func (proxy *GenCalculatorProxy) Reset() {
+	proxy.resetFunc()
+}
+
+// Raw invokes the remote method Raw
+func (proxy *GenCalculatorProxy) Raw(name string) (r0 []byte, err error) {
+	return proxy.rawFunc(name)
+}
+
+// Fail invokes the remote method Fail
+func (proxy *GenCalculatorProxy) Fail() (err error) {
+	return proxy.failFunc()
+}
+
+func init() {
+	hprose.RegisterProxy((*GenCalculator)(nil), func(stub *hprose.Stub) interface{} {
+		return &GenCalculatorProxy{
+			swapFunc:  stub.Func("Swap").(func(int, int) (int, int)),
+			sumFunc:   stub.Func("Sum").(func(...int) (int, error)),
+			greetFunc: stub.Func("Greet").(func(context.Context, string) (string, error)),
+			nowFunc:   stub.Func("Now").(func() time.Time),
+			resetFunc: stub.Func("Reset").(func()),
+			rawFunc:   stub.Func("Raw").(func(string) ([]byte, error)),
+			failFunc:  stub.Func("Fail").(func() error),
+		}
+	})
+}
+
+// GenCalculatorClient calls the methods of GenCalculator without reflection,
+// or by the struct stub when the client has a codec
+type GenCalculatorClient struct {
+	Client hprose.Client
+	stub   *GenCalculatorStub
+}
+
+// NewGenCalculatorClient is the constructor of GenCalculatorClient
+func NewGenCalculatorClient(client hprose.Client) *GenCalculatorClient {
+	c := &GenCalculatorClient{Client: client}
+	client.UseService(&c.stub)
+	return c
+}
+
+// Swap invokes the remote method Swap
+func (client *GenCalculatorClient) Swap(a int, b int) (r0 int, r1 int) {
+	if client.Client.Codec() != nil {
+		return client.stub.Swap(a, b)
+	}
+	err := client.Client.InvokeWith(context.Background(), "Swap", nil, func(writer *hprose.Writer) error {
+		if err := writer.WriteListHeader(2); err != nil {
+			return err
+		}
+		if err := writer.Serialize(a); err != nil {
+			return err
+		}
+		if err := writer.Serialize(b); err != nil {
+			return err
+		}
+		return writer.WriteListFooter()
+	}, func(reader *hprose.Reader) error {
+		if _, err := reader.ReadListHeader(); err != nil {
+			return err
+		}
+		if err := reader.Unserialize(&r0); err != nil {
+			return err
+		}
+		if err := reader.Unserialize(&r1); err != nil {
+			return err
+		}
+		return reader.ReadListFooter()
+	})
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// Sum invokes the remote method Sum
+func (client *GenCalculatorClient) Sum(nums ...int) (r0 int, err error) {
+	if client.Client.Codec() != nil {
+		return client.stub.Sum(nums...)
+	}
+	err = client.Client.InvokeWith(context.Background(), "Sum", nil, func(writer *hprose.Writer) error {
+		if err := writer.WriteListHeader(len(nums)); err != nil {
+			return err
+		}
+		for _, arg := range nums {
+			if err := writer.Serialize(arg); err != nil {
+				return err
+			}
+		}
+		return writer.WriteListFooter()
+	}, func(reader *hprose.Reader) error {
+		return reader.Unserialize(&r0)
+	})
+	return
+}
+
+// Greet invokes the remote method hello
+func (client *GenCalculatorClient) Greet(ctx context.Context, name string) (r0 string, err error) {
+	if client.Client.Codec() != nil {
+		return client.stub.Greet(ctx, name)
+	}
+	err = client.Client.InvokeWith(ctx, "hello", &hprose.InvokeOptions{SimpleMode: true}, func(writer *hprose.Writer) error {
+		if err := writer.WriteListHeader(1); err != nil {
+			return err
+		}
+		if err := writer.Serialize(name); err != nil {
+			return err
+		}
+		return writer.WriteListFooter()
+	}, func(reader *hprose.Reader) error {
+		return reader.Unserialize(&r0)
+	})
+	return
+}
+
+// Now invokes the remote method Now
+func (client *GenCalculatorClient) Now() (r0 time.Time) {
+	if client.Client.Codec() != nil {
+		return client.stub.Now()
+	}
+	err := client.Client.InvokeWith(context.Background(), "Now", &hprose.InvokeOptions{Idempotent: true}, nil, func(reader *hprose.Reader) error {
+		return reader.Unserialize(&r0)
+	})
+	if err != nil {
+		panic(err)
+	}
+	return
+}
+
+// Reset invokes the remote method Reset
+func (client *GenCalculatorClient) Reset() {
+	if client.Client.Codec() != nil {
+		client.stub.Reset()
+		return
+	}
+	err := client.Client.InvokeWith(context.Background(), "Reset", nil, nil, nil)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Raw invokes the remote method Raw
+func (client *GenCalculatorClient) Raw(name string) (r0 []byte, err error) {
+	if client.Client.Codec() != nil {
+		return client.stub.Raw(name)
+	}
+	err = func() error {
+		args := []interface{}{name}
+		return <-client.Client.InvokeContext(context.Background(), "Raw", args, &hprose.InvokeOptions{ResultMode: hprose.Raw}, &r0)
+	}()
+	return
+}
+
+// Fail invokes the remote method Fail
+func (client *GenCalculatorClient) Fail() (err error) {
+	if client.Client.Codec() != nil {
+		return client.stub.Fail()
+	}
+	err = client.Client.InvokeWith(context.Background(), "Fail", nil, nil, nil)
+	return
+}
+
+// RegisterGenCalculator publish the methods of GenCalculator to methods
+func RegisterGenCalculator(methods *hprose.Methods, impl GenCalculator) {
+	methods.AddFunction("Swap", impl.Swap, hprose.ParamNames{"a", "b"})
+	methods.AddFunction("Sum", impl.Sum)
+	methods.AddFunction("hello", impl.Greet, true, hprose.ParamNames{"name"})
+	methods.AddFunction("Now", impl.Now)
+	methods.AddFunction("Reset", impl.Reset)
+	methods.AddFunction("Raw", impl.Raw, hprose.Raw, hprose.ParamNames{"name"})
+	methods.AddFunction("Fail", impl.Fail)
+}
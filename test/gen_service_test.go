@@ -0,0 +1,176 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/gen_service_test.go                             *
+ *                                                        *
+ * hprose generated service Test for Go.                  *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../hprose"
+)
+
+//go:generate go run ../cmd/hprose-gen/main.go ../cmd/hprose-gen/generator.go ../cmd/hprose-gen/codec.go -type=GenCalculator -output=gen_service_hprose_test.go -hprose=../hprose
+
+// GenCalculator is the service interface for the generated code test
+type GenCalculator interface {
+	Swap(a, b int) (int, int)
+	Sum(nums ...int) (int, error)
+	// hprose:name=hello simple
+	Greet(ctx context.Context, name string) (string, error)
+	// hprose:idempotent
+	Now() time.Time
+	Reset()
+	// hprose:result=raw
+	Raw(name string) ([]byte, error)
+	Fail() error
+}
+
+type genCalculator struct{}
+
+func (genCalculator) Swap(a, b int) (int, int) {
+	return b, a
+}
+
+func (genCalculator) Sum(nums ...int) (sum int, err error) {
+	for _, n := range nums {
+		sum += n
+	}
+	return sum, nil
+}
+
+func (genCalculator) Greet(ctx context.Context, name string) (string, error) {
+	return hello(name), nil
+}
+
+func (genCalculator) Now() time.Time {
+	return time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+}
+
+func (genCalculator) Reset() {}
+
+func (genCalculator) Raw(name string) ([]byte, error) {
+	return []byte(`Rs` + strconv.Itoa(len(name)) + `"` + name + `"`), nil
+}
+
+func (genCalculator) Fail() error {
+	return errors.New("failed")
+}
+
+func TestHttpServiceGenerated(t *testing.T) {
+	service := hprose.NewHttpService()
+	RegisterGenCalculator(service.Methods, genCalculator{})
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	var calc GenCalculator = NewGenCalculatorClient(client)
+	if a, b := calc.Swap(1, 2); a != 2 || b != 1 {
+		t.Error(a, b)
+	}
+	if sum, err := calc.Sum(1, 2, 3); err != nil || sum != 6 {
+		t.Error(sum, err)
+	}
+	if s, err := calc.Greet(context.Background(), "world"); err != nil || s != "Hello world!" {
+		t.Error(s, err)
+	}
+	if now := calc.Now(); !now.Equal(genCalculator{}.Now()) {
+		t.Error(now)
+	}
+	calc.Reset()
+	if raw, err := calc.Raw("world"); err != nil || string(raw) != `Rs5"world"` {
+		t.Error(string(raw), err)
+	}
+	if err := calc.Fail(); err == nil || err.Error() != "failed" {
+		t.Error(err)
+	}
+	var stub *GenCalculatorStub
+	client.UseService(&stub)
+	if s, err := stub.Greet(context.Background(), "stub"); err != nil || s != "Hello stub!" {
+		t.Error(s, err)
+	}
+}
+
+func TestHttpServiceGeneratedProxy(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddMethods(genCalculator{}, (*GenCalculator)(nil), GenCalculatorOptions)
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	var calc GenCalculator
+	client.UseService(&calc, GenCalculatorOptions)
+	if _, ok := calc.(*GenCalculatorProxy); !ok {
+		t.Fatal(calc)
+	}
+	if a, b := calc.Swap(1, 2); a != 2 || b != 1 {
+		t.Error(a, b)
+	}
+	if sum, err := calc.Sum(1, 2, 3); err != nil || sum != 6 {
+		t.Error(sum, err)
+	}
+	if s, err := calc.Greet(context.Background(), "proxy"); err != nil || s != "Hello proxy!" {
+		t.Error(s, err)
+	}
+	if raw, err := calc.Raw("world"); err != nil || string(raw) != `Rs5"world"` {
+		t.Error(string(raw), err)
+	}
+	if err := calc.Fail(); err == nil || err.Error() != "failed" {
+		t.Error(err)
+	}
+}
+
+func TestHttpServiceGeneratedIdempotent(t *testing.T) {
+	service := hprose.NewHttpService()
+	RegisterGenCalculator(service.Methods, genCalculator{})
+	var requests, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		service.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := hprose.NewHttpClient(server.URL)
+	client.RetryPolicy = &hprose.RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	if !GenCalculatorOptions["Now"].Idempotent || GenCalculatorOptions["Greet"].Idempotent {
+		t.Error(GenCalculatorOptions)
+	}
+	calc := NewGenCalculatorClient(client)
+	var proxy GenCalculator
+	client.UseService(&proxy, GenCalculatorOptions)
+	var stub *GenCalculatorStub
+	client.UseService(&stub)
+	for _, now := range []func() time.Time{calc.Now, proxy.Now, stub.Now} {
+		requests, failures = 0, 1
+		if n := now(); !n.Equal(genCalculator{}.Now()) || requests != 2 {
+			t.Error(n, requests)
+		}
+	}
+	requests, failures = 0, 1
+	if s, err := calc.Greet(context.Background(), "world"); err == nil || requests != 1 {
+		t.Error(s, err, requests)
+	}
+}
@@ -0,0 +1,197 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/handler_test.go                                 *
+ *                                                        *
+ * hprose handler Test for Go.                            *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"../hprose"
+)
+
+func TestInvokeHandler(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("hello", hello)
+	var calls []string
+	service.Use(func(name string, args []reflect.Value, context hprose.Context, next hprose.NextInvokeHandler) ([]reflect.Value, error) {
+		calls = append(calls, name)
+		return next(name, args, context)
+	}, func(name string, args []reflect.Value, context hprose.Context, next hprose.NextInvokeHandler) ([]reflect.Value, error) {
+		if args[0].String() == "guest" {
+			return nil, errors.New("access denied")
+		}
+		if args[0].String() == "cache" {
+			return []reflect.Value{reflect.ValueOf("cached")}, nil
+		}
+		args[0] = reflect.ValueOf(strings.ToUpper(args[0].String()))
+		results, err := next(name, args, context)
+		if err == nil {
+			results[0] = reflect.ValueOf(results[0].String() + "!")
+		}
+		return results, err
+	})
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	var s string
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello WORLD!!" {
+		t.Error(s, err)
+	}
+	if err := <-client.Invoke("hello", []interface{}{"guest"}, nil, &s); err == nil || err.Error() != "access denied" {
+		t.Error(err)
+	}
+	if err := <-client.Invoke("hello", []interface{}{"cache"}, nil, &s); err != nil || s != "cached" {
+		t.Error(s, err)
+	}
+	if err := <-client.Invoke("missing", []interface{}{"world"}, nil, &s); err == nil {
+		t.Error("missing method must fail")
+	}
+	if !reflect.DeepEqual(calls, []string{"hello", "hello", "hello"}) {
+		t.Error(calls)
+	}
+	var names []string
+	var seen []interface{}
+	cache := map[string]string{"cached": "from cache"}
+	client.Use(func(name string, args []reflect.Value, context hprose.Context, next hprose.NextInvokeHandler) ([]reflect.Value, error) {
+		names = append(names, name)
+		if _, ok := context.(*hprose.ClientContext); !ok {
+			t.Error(context)
+		}
+		if len(args) > 0 {
+			seen = append(seen, args[0].Interface())
+			if r, ok := cache[args[0].Interface().(string)]; ok {
+				return []reflect.Value{reflect.ValueOf(r)}, nil
+			}
+			if args[0].Interface() == "alias" {
+				args[0] = reflect.ValueOf("world")
+			}
+		}
+		return next(name, args, context)
+	})
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello WORLD!!" {
+		t.Error(s, err)
+	}
+	if err := <-client.Invoke("hello", []interface{}{"cached"}, nil, &s); err != nil || s != "from cache" {
+		t.Error(s, err)
+	}
+	var sc <-chan string
+	if err := <-client.Invoke("hello", []interface{}{"cached"}, nil, &sc); err != nil || <-sc != "from cache" {
+		t.Error(err)
+	}
+	err := client.InvokeWith(context.Background(), "hello", nil, func(writer *hprose.Writer) error {
+		return writer.Serialize([]interface{}{"world"})
+	}, func(reader *hprose.Reader) error {
+		return reader.Unserialize(&s)
+	})
+	if err != nil || s != "Hello WORLD!!" {
+		t.Error(s, err)
+	}
+	tests := []struct{ arg, expected string }{{"cached", "from cache"}, {"alias", "Hello WORLD!!"}}
+	for _, test := range tests {
+		s = ""
+		err = client.InvokeWith(context.Background(), "hello", nil, func(writer *hprose.Writer) error {
+			return writer.Serialize([]interface{}{test.arg})
+		}, func(reader *hprose.Reader) error {
+			return reader.Unserialize(&s)
+		})
+		if err != nil || s != test.expected {
+			t.Error(test.arg, s, err)
+		}
+	}
+	if !reflect.DeepEqual(names, []string{"hello", "hello", "hello", "hello", "hello", "hello"}) {
+		t.Error(names)
+	}
+	if !reflect.DeepEqual(seen, []interface{}{"world", "cached", "cached", "world", "cached", "alias"}) {
+		t.Error(seen)
+	}
+}
+
+func TestFilterHandler(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("hello", hello)
+	service.AddFilter(hprose.NewCompressionFilter(hprose.CompressionGzip))
+	var trace []string
+	service.AddBeforeFilterHandler(func(request []byte, context hprose.Context, next hprose.NextFilterHandler) ([]byte, error) {
+		trace = append(trace, "service before "+string(request[:1]))
+		response, err := next(request, context)
+		if err == nil {
+			trace = append(trace, "service before "+string(response[:1]))
+		}
+		return response, err
+	})
+	service.AddAfterFilterHandler(func(request []byte, context hprose.Context, next hprose.NextFilterHandler) ([]byte, error) {
+		trace = append(trace, "service after "+string(request[:1]))
+		if strings.Contains(string(request), "guest") {
+			return nil, errors.New("access denied")
+		}
+		response, err := next(request, context)
+		trace = append(trace, "service after "+string(response[:1]))
+		return response, err
+	})
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	client.AddFilter(hprose.NewCompressionFilter(hprose.CompressionGzip))
+	client.AddBeforeFilterHandler(func(request []byte, context hprose.Context, next hprose.NextFilterHandler) ([]byte, error) {
+		trace = append(trace, "client before "+string(request[:1]))
+		response, err := next(request, context)
+		if err == nil {
+			trace = append(trace, "client before "+string(response[:1]))
+		}
+		return response, err
+	})
+	client.AddAfterFilterHandler(func(request []byte, context hprose.Context, next hprose.NextFilterHandler) ([]byte, error) {
+		if strings.Contains(string(request), "offline") {
+			return nil, errors.New("offline")
+		}
+		trace = append(trace, "client after "+string(request[:1]))
+		response, err := next(request, context)
+		if err == nil {
+			trace = append(trace, "client after "+string(response[:1]))
+		}
+		return response, err
+	})
+	var s string
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello world!" {
+		t.Error(s, err)
+	}
+	c1 := string([]byte{0xc1})
+	if !reflect.DeepEqual(trace, []string{
+		"client before C", "client after " + c1,
+		"service before " + c1, "service after C", "service after R", "service before " + c1,
+		"client after " + c1, "client before R",
+	}) {
+		t.Error(trace)
+	}
+	trace = nil
+	if err := <-client.Invoke("hello", []interface{}{"guest"}, nil, &s); err == nil || err.Error() != "access denied" {
+		t.Error(err)
+	}
+	if err := <-client.Invoke("hello", []interface{}{"offline"}, nil, &s); err == nil || err.Error() != "offline" {
+		t.Error(err)
+	}
+	if len(trace) != 7 || trace[5] != "client before E" || trace[6] != "client before C" {
+		t.Error(trace)
+	}
+}
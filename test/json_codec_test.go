@@ -0,0 +1,210 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/json_codec_test.go                              *
+ *                                                        *
+ * hprose json codec Test for Go.                         *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"../hprose"
+)
+
+func TestJSONCodecMarshal(t *testing.T) {
+	codec := hprose.NewJSONCodec()
+	born := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := &testProfile{Nick: "tom", Born: born, Secret: "x"}
+	data, err := codec.Marshal(reflect.ValueOf(p))
+	if err != nil || string(data) != `{"nick":"tom","born":"2000-01-02T03:04:05Z"}` {
+		t.Error(string(data), err)
+	}
+	var q *testProfile
+	if err = codec.Unmarshal(data, reflect.ValueOf(&q).Elem()); err != nil || q.Nick != "tom" || !q.Born.Equal(born) {
+		t.Error(q, err)
+	}
+	m := testMember{Name: "a", Friends: []*testMember{{Name: "b"}}, Groups: map[string]int{"x": 1}}
+	data, err = codec.Marshal(reflect.ValueOf(m))
+	if err != nil || string(data) != `{"name":"a","friends":[{"name":"b","friends":null,"groups":null}],"groups":{"x":1}}` {
+		t.Error(string(data), err)
+	}
+	var n testMember
+	if err = codec.Unmarshal(data, reflect.ValueOf(&n).Elem()); err != nil || !reflect.DeepEqual(m, n) {
+		t.Error(n, err)
+	}
+	var v interface{}
+	if err = codec.Unmarshal([]byte(`[1,2.5,"s",{"k":null}]`), reflect.ValueOf(&v).Elem()); err != nil ||
+		!reflect.DeepEqual(v, []interface{}{int64(1), 2.5, "s", map[string]interface{}{"k": nil}}) {
+		t.Error(v, err)
+	}
+}
+
+func TestHttpServiceJSONCodec(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddCodec(hprose.NewJSONCodec())
+	service.AddFunction("hello", hello)
+	service.AddMethods(new(testServe))
+	service.AddFunction("nick", func(p *testProfile) string { return p.Nick })
+	service.AddFunction("sub", func(a, b int) int { return a - b }, hprose.ParamNames{"a", "b"})
+	service.AddFunction("codeError", func() error { return testCodeError{1001} })
+	service.AddFunction("swapKeyAndValue", func(m *map[string]string) map[string]string {
+		o := *m
+		*m = make(map[string]string)
+		for k, v := range o {
+			(*m)[v] = k
+		}
+		return o
+	})
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	client.SetCodec(hprose.NewJSONCodec())
+	var ro *testRemoteObject2
+	client.UseService(&ro)
+	if s, err := ro.Hello("World"); err != nil || s != "Hello World!" {
+		t.Error(s, err)
+	}
+	if a, b, err := ro.Swap(1, 2); err != nil || a != 2 || b != 1 {
+		t.Error(a, b, err)
+	}
+	if sum, err := ro.Sum(1); err == nil || err.Error() != "Requires at least two parameters" {
+		t.Error(sum, err)
+	}
+	var nick string
+	if err := <-client.Invoke("nick", []interface{}{&testProfile{Nick: "tom"}}, nil, &nick); err != nil || nick != "tom" {
+		t.Error(nick, err)
+	}
+	m := map[string]string{"k": "v"}
+	var r map[string]string
+	if err := <-client.Invoke("swapKeyAndValue", []interface{}{&m}, &hprose.InvokeOptions{ByRef: true}, &r); err != nil ||
+		!reflect.DeepEqual(m, map[string]string{"v": "k"}) || !reflect.DeepEqual(r, map[string]string{"k": "v"}) {
+		t.Error(m, r, err)
+	}
+	var e interface{}
+	err := <-client.Invoke("codeError", nil, nil, &e)
+	if re, ok := err.(*hprose.RemoteError); !ok || re.Code != 1001 || !reflect.DeepEqual(re.Data, map[string]interface{}{"field": "name"}) {
+		t.Error(err)
+	}
+	batch := client.Batch()
+	var diff int
+	var hi string
+	err1 := batch.Invoke("sub", []interface{}{5, 3}, nil, &diff)
+	err2 := batch.Invoke("hello", []interface{}{"batch"}, &hprose.InvokeOptions{Oneway: true}, &hi)
+	err3 := batch.Invoke("nothing", nil, nil, &e)
+	if err = batch.End(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-err1; err != nil || diff != 2 {
+		t.Error(diff, err)
+	}
+	if err = <-err2; err != nil || hi != "" {
+		t.Error(hi, err)
+	}
+	if err = <-err3; err == nil {
+		t.Error("missing error")
+	}
+	resp, err := http.Post(server.URL, "application/json; charset=utf-8",
+		strings.NewReader(`{"jsonrpc":"2.0","id":"a","method":"sub","params":{"b":1,"a":3}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "application/json" || string(data) != `{"jsonrpc":"2.0","id":"a","result":2}` {
+		t.Error(resp.Header.Get("Content-Type"), string(data))
+	}
+	resp, err = http.Post(server.URL, "application/json",
+		strings.NewReader(`[{"jsonrpc":"2.0","id":1,"method":"sub","params":[3,1]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(data) != `[{"jsonrpc":"2.0","id":1,"result":2}]` {
+		t.Error(string(data))
+	}
+	tests := []struct{ request, response string }{
+		{`{"jsonrpc":"2.0","id":1,"method":"nothing"}`,
+			`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Can't find this method nothing"}}`},
+		{`{"jsonrpc":"2.0","id":2,"method":"sub","params":["a",1]}`,
+			`{"jsonrpc":"2.0","id":2,"error":{"code":-32602,"message":"json: cannot unmarshal string into Go value of type int"}}`},
+		{`[{"jsonrpc":"2.0","id":3,"method":"sub","params":[3,1]},5]`,
+			`[{"jsonrpc":"2.0","id":3,"result":2},` +
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"json: cannot unmarshal number into Go value of type hprose.jsonCodecRequest"}}]`},
+		{`{"jsonrpc":"2.0","id":4,`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`},
+	}
+	for _, test := range tests {
+		resp, err = http.Post(server.URL, "application/json", strings.NewReader(test.request))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(data) != test.response {
+			t.Error(test.request, string(data))
+		}
+	}
+	var s string
+	if err = <-hprose.NewClient(server.URL).Invoke("hello", []interface{}{"hprose"}, nil, &s); err != nil || s != "Hello hprose!" {
+		t.Error(s, err)
+	}
+}
+
+func TestHttpServiceJSONCodecGenerated(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddCodec(hprose.NewJSONCodec())
+	RegisterGenCalculator(service.Methods, genCalculator{})
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	client.SetCodec(hprose.NewJSONCodec())
+	calc := NewGenCalculatorClient(client)
+	if a, b := calc.Swap(1, 2); a != 2 || b != 1 {
+		t.Error(a, b)
+	}
+	if sum, err := calc.Sum(1, 2, 3); err != nil || sum != 6 {
+		t.Error(sum, err)
+	}
+	if s, err := calc.Greet(context.Background(), "codec"); err != nil || s != "Hello codec!" {
+		t.Error(s, err)
+	}
+	if err := calc.Fail(); err == nil || err.Error() != "failed" {
+		t.Error(err)
+	}
+}
+
+func TestTcpClientSetCodec(t *testing.T) {
+	client := hprose.NewTcpClient("tcp://127.0.0.1:4321")
+	defer client.Close()
+	defer func() {
+		if e := recover(); e == nil {
+			t.Error("SetCodec should panic")
+		}
+		if client.Codec() != nil {
+			t.Error(client.Codec())
+		}
+	}()
+	client.SetCodec(hprose.NewJSONCodec())
+}
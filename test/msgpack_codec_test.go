@@ -0,0 +1,159 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/msgpack_codec_test.go                           *
+ *                                                        *
+ * hprose msgpack codec Test for Go.                      *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"../hprose"
+)
+
+func TestMsgPackCodecMarshal(t *testing.T) {
+	codec := hprose.NewMsgPackCodec()
+	cases := []struct {
+		value interface{}
+		data  []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{1, []byte{0x01}},
+		{-1, []byte{0xff}},
+		{200, []byte{0xcc, 0xc8}},
+		{-200, []byte{0xd1, 0xff, 0x38}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"ab", []byte{0xa2, 'a', 'b'}},
+		{[]byte{1, 2}, []byte{0xc4, 2, 1, 2}},
+		{[]int{1, 2}, []byte{0x92, 1, 2}},
+		{map[string]int{"a": 1}, []byte{0x81, 0xa1, 'a', 1}},
+		{time.Unix(1, 0), []byte{0xd6, 0xff, 0, 0, 0, 1}},
+	}
+	for _, c := range cases {
+		data, err := codec.Marshal(reflect.ValueOf(c.value))
+		if err != nil || !bytes.Equal(data, c.data) {
+			t.Errorf("%v: % x %v", c.value, data, err)
+		}
+	}
+	born := time.Date(2000, 1, 2, 3, 4, 5, 6, time.UTC)
+	p := &testProfile{Nick: "tom", Born: born, Secret: "x"}
+	data, err := codec.Marshal(reflect.ValueOf(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q *testProfile
+	if err = codec.Unmarshal(data, reflect.ValueOf(&q).Elem()); err != nil || q.Nick != "tom" || !q.Born.Equal(born) || q.Secret != "" {
+		t.Error(q, err)
+	}
+	var m map[string]interface{}
+	if err = codec.Unmarshal(data, reflect.ValueOf(&m).Elem()); err != nil || len(m) != 2 || m["nick"] != "tom" {
+		t.Error(m, err)
+	}
+	var v interface{}
+	data, _ = codec.Marshal(reflect.ValueOf([]interface{}{1, -200, "s", true, nil, 1.5, []byte{1}}))
+	if err = codec.Unmarshal(data, reflect.ValueOf(&v).Elem()); err != nil ||
+		!reflect.DeepEqual(v, []interface{}{int64(1), int64(-200), "s", true, nil, 1.5, []byte{1}}) {
+		t.Error(v, err)
+	}
+	if err = codec.Unmarshal([]byte{0x92, 1}, reflect.ValueOf(&v).Elem()); err == nil {
+		t.Error("missing error")
+	}
+}
+
+func TestHttpServiceMsgPackCodec(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddCodec(hprose.NewJSONCodec())
+	service.AddCodec(hprose.NewMsgPackCodec(), "application/x-msgpack")
+	service.AddFunction("hello", hello)
+	service.AddMethods(new(testServe))
+	service.AddFunction("nick", func(p *testProfile) string { return p.Nick })
+	service.AddFunction("sub", func(a, b int) int { return a - b }, hprose.ParamNames{"a", "b"})
+	service.AddFunction("codeError", func() error { return testCodeError{1001} })
+	service.AddFunction("double", func(a *[]int) {
+		for i := range *a {
+			(*a)[i] *= 2
+		}
+	})
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	client.SetCodec(hprose.NewMsgPackCodec())
+	var ro *testRemoteObject2
+	client.UseService(&ro)
+	if s, err := ro.Hello("World"); err != nil || s != "Hello World!" {
+		t.Error(s, err)
+	}
+	if a, b, err := ro.Swap(1, 2); err != nil || a != 2 || b != 1 {
+		t.Error(a, b, err)
+	}
+	if sum, err := ro.Sum(1); err == nil || err.Error() != "Requires at least two parameters" {
+		t.Error(sum, err)
+	}
+	var nick string
+	if err := <-client.Invoke("nick", []interface{}{&testProfile{Nick: "tom"}}, nil, &nick); err != nil || nick != "tom" {
+		t.Error(nick, err)
+	}
+	a := []int{1, 2}
+	var r interface{}
+	if err := <-client.Invoke("double", []interface{}{&a}, &hprose.InvokeOptions{ByRef: true}, &r); err != nil ||
+		!reflect.DeepEqual(a, []int{2, 4}) {
+		t.Error(a, err)
+	}
+	err := <-client.Invoke("codeError", nil, nil, &r)
+	if re, ok := err.(*hprose.RemoteError); !ok || re.Code != 1001 || !reflect.DeepEqual(re.Data, map[string]interface{}{"field": "name"}) {
+		t.Error(err)
+	}
+	batch := client.Batch()
+	var diff int
+	var hi string
+	err1 := batch.Invoke("sub", []interface{}{5, 3}, nil, &diff)
+	err2 := batch.Invoke("hello", []interface{}{"batch"}, &hprose.InvokeOptions{Oneway: true}, &hi)
+	err3 := batch.Invoke("hello", []interface{}{"batch"}, nil, &hi)
+	if err = batch.End(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-err1; err != nil || diff != 2 {
+		t.Error(diff, err)
+	}
+	if err = <-err2; err != nil {
+		t.Error(err)
+	}
+	if err = <-err3; err != nil || hi != "Hello batch!" {
+		t.Error(hi, err)
+	}
+	request := []byte{0x94, 0x00, 0x07, 0xa3, 's', 'u', 'b', 0x82, 0xa1, 'a', 0x03, 0xa1, 'b', 0x01}
+	resp, err := http.Post(server.URL, "application/x-msgpack", bytes.NewReader(request))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "application/msgpack" || !bytes.Equal(data, []byte{0x94, 0x01, 0x07, 0xc0, 0x02}) {
+		t.Errorf("%s % x", resp.Header.Get("Content-Type"), data)
+	}
+	var s string
+	if err = <-hprose.NewClient(server.URL).Invoke("hello", []interface{}{"hprose"}, nil, &s); err != nil || s != "Hello hprose!" {
+		t.Error(s, err)
+	}
+}
@@ -12,7 +12,7 @@
  *                                                        *
  * hprose Writer Test for Go.                             *
  *                                                        *
- * LastModified: Jun 19, 2014                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -22,7 +22,11 @@ package hprose_test
 import (
 	"bytes"
 	"container/list"
+	"errors"
 	. "../hprose"
+	"math/big"
+	"net"
+	"net/url"
 	"reflect"
 	"strings"
 	"testing"
@@ -348,3 +352,245 @@ func TestReaderArray(t *testing.T) {
 		t.Error(result)
 	}
 }
+
+func TestReaderUnmarshaler(t *testing.T) {
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, false)
+	tip := testMoney{200}
+	order := testOrder{
+		Price:  testMoney{1234},
+		Prices: []testMoney{{5}, {9900}},
+		Totals: map[string]testMoney{"total": {9905}},
+		Tip:    &tip,
+	}
+	writer.Serialize(&order)
+	writer.Serialize(&tip)
+	reader := NewReader(b, false)
+	var x testOrder
+	if err := reader.Unserialize(&x); err != nil {
+		t.Error(err.Error())
+	}
+	if !reflect.DeepEqual(x, order) {
+		t.Error(x, order)
+	}
+	var m testMoney
+	if err := reader.Unserialize(&m); err != nil {
+		t.Error(err.Error())
+	}
+	if m != tip {
+		t.Error(m, tip)
+	}
+}
+
+func TestReaderUnmarshalerNil(t *testing.T) {
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, false)
+	order := testOrder{Price: testMoney{1234}}
+	writer.Serialize(&order)
+	reader := NewReader(NewBytesReader(b.Bytes()), false)
+	x := testOrder{Tip: &testMoney{100}}
+	if err := reader.Unserialize(&x); err != nil {
+		t.Error(err.Error())
+	}
+	if x.Tip != nil {
+		t.Error(x.Tip)
+	}
+	if x.Price != order.Price {
+		t.Error(x.Price, order.Price)
+	}
+}
+
+type testHost struct {
+	IP   net.IP
+	IPs  []net.IP
+	Home *url.URL
+}
+
+func registerTestConverters() {
+	ConverterManager.Register(reflect.TypeOf(net.IP{}),
+		func(writer *Writer, v interface{}) error {
+			if ip := v.(net.IP); ip != nil {
+				return writer.WriteString(ip.String())
+			}
+			return writer.WriteNull()
+		},
+		func(reader *Reader) (interface{}, error) {
+			str, err := reader.ReadString()
+			if err != nil {
+				return nil, err
+			}
+			return net.ParseIP(str), nil
+		})
+	ConverterManager.Register(reflect.TypeOf(url.URL{}),
+		func(writer *Writer, v interface{}) error {
+			u := v.(url.URL)
+			return writer.WriteString(u.String())
+		},
+		func(reader *Reader) (interface{}, error) {
+			str, err := reader.ReadString()
+			if err != nil {
+				return nil, err
+			}
+			return url.Parse(str)
+		})
+}
+
+func unregisterTestConverters() {
+	ConverterManager.Unregister(reflect.TypeOf(net.IP{}))
+	ConverterManager.Unregister(reflect.TypeOf(url.URL{}))
+}
+
+func TestReaderConverter(t *testing.T) {
+	registerTestConverters()
+	defer unregisterTestConverters()
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, true)
+	home, _ := url.Parse("http://www.hprose.com/")
+	host := testHost{
+		IP:   net.ParseIP("127.0.0.1"),
+		IPs:  []net.IP{net.ParseIP("::1")},
+		Home: home,
+	}
+	writer.Serialize(&host)
+	writer.Serialize(testHost{})
+	s := `c8"testHost"3{s2"iP"s3"iPs"s4"home"}o0{s9"127.0.0.1"a1{s3"::1"}s22"http://www.hprose.com/"}o0{nnn}`
+	if b.String() != s {
+		t.Error(b.String())
+	}
+	reader := NewReader(b, true)
+	var x testHost
+	if err := reader.Unserialize(&x); err != nil {
+		t.Error(err.Error())
+	}
+	if !reflect.DeepEqual(x, host) {
+		t.Error(x, host)
+	}
+	if err := reader.Unserialize(&x); err != nil {
+		t.Error(err.Error())
+	}
+	if x.Home != nil || x.IPs != nil {
+		t.Error(x)
+	}
+}
+
+func TestReaderNativeConverter(t *testing.T) {
+	ConverterManager.Register(reflect.TypeOf(big.Rat{}),
+		func(writer *Writer, v interface{}) error {
+			r := v.(big.Rat)
+			return writer.WriteString(r.String())
+		},
+		func(reader *Reader) (interface{}, error) {
+			str, err := reader.ReadString()
+			if err != nil {
+				return nil, err
+			}
+			r, ok := new(big.Rat).SetString(str)
+			if !ok {
+				return nil, errors.New("invalid rat: " + str)
+			}
+			return r, nil
+		})
+	defer ConverterManager.Unregister(reflect.TypeOf(big.Rat{}))
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, true)
+	rat := big.NewRat(-7, 3)
+	writer.Serialize(rat)
+	writer.Serialize(*rat)
+	writer.Serialize([]*big.Rat{rat})
+	writer.Serialize((*big.Rat)(nil))
+	s := `s4"-7/3"s4"-7/3"a1{s4"-7/3"}n`
+	if b.String() != s {
+		t.Error(b.String())
+	}
+	reader := NewReader(b, true)
+	var p *big.Rat
+	var r big.Rat
+	var a []*big.Rat
+	if err := reader.Unserialize(&p); err != nil || p.Cmp(rat) != 0 {
+		t.Error(p, err)
+	}
+	if err := reader.Unserialize(&r); err != nil || r.Cmp(rat) != 0 {
+		t.Error(&r, err)
+	}
+	if err := reader.Unserialize(&a); err != nil || len(a) != 1 || a[0].Cmp(rat) != 0 {
+		t.Error(a, err)
+	}
+	if err := reader.Unserialize(&p); err != nil || p != nil {
+		t.Error(p, err)
+	}
+}
+
+func TestReaderConverterBeforeFastPath(t *testing.T) {
+	ConverterManager.Register(reflect.TypeOf(time.Time{}),
+		func(writer *Writer, v interface{}) error {
+			return writer.WriteString(v.(time.Time).Format(time.RFC3339))
+		},
+		func(reader *Reader) (interface{}, error) {
+			str, err := reader.ReadString()
+			if err != nil {
+				return nil, err
+			}
+			return time.Parse(time.RFC3339, str)
+		})
+	defer ConverterManager.Unregister(reflect.TypeOf(time.Time{}))
+	now := time.Date(2016, 5, 1, 12, 30, 0, 0, time.UTC)
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, true)
+	writer.Serialize(now)
+	writer.Serialize(&now)
+	s := `s20"2016-05-01T12:30:00Z"s20"2016-05-01T12:30:00Z"`
+	if b.String() != s {
+		t.Error(b.String())
+	}
+	reader := NewReader(b, true)
+	var x time.Time
+	var p *time.Time
+	if err := reader.Unserialize(&x); err != nil || !x.Equal(now) {
+		t.Error(x, err)
+	}
+	if err := reader.Unserialize(&p); err != nil || p == nil || !p.Equal(now) {
+		t.Error(p, err)
+	}
+}
+
+type testNumbers struct {
+	Rat      big.Rat
+	Float    *big.Float
+	Complex  complex128
+	Complex2 *complex64
+	Duration time.Duration
+}
+
+func TestReaderNumbers(t *testing.T) {
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, false)
+	f, _, _ := big.ParseFloat("3.14159265358979323846264338327950288", 10, 128, big.ToNearestEven)
+	c := complex64(2i)
+	n := testNumbers{*big.NewRat(-7, 3), f, complex(1.5, -2), &c, 90 * time.Second}
+	writer.Serialize(&n)
+	writer.Serialize([]interface{}{1, "2.5"})
+	writer.Serialize("1m30s")
+	reader := NewReader(b, false)
+	var x testNumbers
+	if err := reader.Unserialize(&x); err != nil {
+		t.Error(err.Error())
+	}
+	if x.Rat.Cmp(&n.Rat) != 0 || x.Float.SetPrec(f.Prec()).Cmp(f) != 0 ||
+		x.Complex != n.Complex || *x.Complex2 != c || x.Duration != n.Duration {
+		t.Error(x, n)
+	}
+	var y complex128
+	if err := reader.Unserialize(&y); err != nil {
+		t.Error(err.Error())
+	}
+	if y != complex(1, 2.5) {
+		t.Error(y)
+	}
+	var d time.Duration
+	if err := reader.Unserialize(&d); err != nil {
+		t.Error(err.Error())
+	}
+	if d != 90*time.Second {
+		t.Error(d)
+	}
+}
@@ -0,0 +1,120 @@
+/**********************************************************\
+|                                                          |
+|                          hprose                          |
+|                                                          |
+| Official WebSite: http://www.hprose.com/                 |
+|                   http://www.hprose.org/                 |
+|                                                          |
+\**********************************************************/
+/**********************************************************\
+ *                                                        *
+ * hprose/retry_policy_test.go                            *
+ *                                                        *
+ * hprose retry policy Test for Go.                       *
+ *                                                        *
+ * LastModified: Oct 18, 2026                             *
+ * Author: Ma Bingyao <andot@hprose.com>                  *
+ *                                                        *
+\**********************************************************/
+
+package hprose_test
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"../hprose"
+)
+
+type testRetryObject struct {
+	Hello       func(string) (string, error) `idempotent:"true"`
+	UnsafeHello func(string) (string, error) `name:"hello"`
+}
+
+func TestRetryPolicy(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("hello", hello)
+	service.AddFunction("fail", func() error { return errors.New("failed") })
+	var requests, failures int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if atomic.AddInt32(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		service.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	client := hprose.NewHttpClient(server.URL)
+	client.RetryPolicy = &hprose.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	var attempt int
+	client.Use(func(name string, args []reflect.Value, context hprose.Context, next hprose.NextInvokeHandler) ([]reflect.Value, error) {
+		results, err := next(name, args, context)
+		attempt = context.(*hprose.ClientContext).Attempt
+		return results, err
+	})
+	var s string
+	idempotent := &hprose.InvokeOptions{Idempotent: true}
+	failures = 2
+	if err := <-client.Invoke("hello", []interface{}{"world"}, idempotent, &s); err != nil || s != "Hello world!" || requests != 3 || attempt != 3 {
+		t.Error(s, err, requests, attempt)
+	}
+	requests, failures = 0, 3
+	err := <-client.Invoke("hello", []interface{}{"world"}, idempotent, &s)
+	if e, ok := err.(*hprose.HttpStatusError); !ok || e.StatusCode != http.StatusBadGateway || requests != 3 || attempt != 3 {
+		t.Error(err, requests, attempt)
+	}
+	requests, failures = 0, 1
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err == nil || requests != 1 || attempt != 1 {
+		t.Error(err, requests, attempt)
+	}
+	requests, failures = 0, 0
+	if err := <-client.Invoke("fail", nil, idempotent, &s); err == nil || err.Error() != "failed" || requests != 1 {
+		t.Error(err, requests)
+	}
+	var ro *testRetryObject
+	client.UseService(&ro)
+	requests, failures = 0, 1
+	if s, err := ro.Hello("world"); err != nil || s != "Hello world!" || requests != 2 || attempt != 2 {
+		t.Error(s, err, requests, attempt)
+	}
+	requests, failures = 0, 1
+	if _, err := ro.UnsafeHello("world"); err == nil || requests != 1 {
+		t.Error(err, requests)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &hprose.RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for i, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if d := policy.Delay(i + 1); d != delay {
+			t.Error(i+1, d)
+		}
+	}
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := policy.Delay(2); d < time.Second || d > 2*time.Second {
+			t.Error(d)
+		}
+	}
+	policy = &hprose.RetryPolicy{BaseDelay: time.Second}
+	if d := policy.Delay(30); d != time.Second<<29 {
+		t.Error(d)
+	}
+	for _, attempt := range []int{64, 100, 1000} {
+		if d := policy.Delay(attempt); d != math.MaxInt64 {
+			t.Error(attempt, d)
+		}
+	}
+	if !hprose.IsRetryable(&hprose.HttpStatusError{StatusCode: 503}) ||
+		hprose.IsRetryable(&hprose.HttpStatusError{StatusCode: 404}) ||
+		hprose.IsRetryable(&hprose.RemoteError{Message: "failed"}) {
+		t.Error("wrong retryable errors")
+	}
+}
@@ -12,7 +12,7 @@
  *                                                        *
  * hprose Service Test for Go.                            *
  *                                                        *
- * LastModified: Mar 15, 2014                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -20,11 +20,19 @@
 package hprose_test
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"../hprose"
 )
@@ -270,3 +278,717 @@ func TestUnixServiceAsync(t *testing.T) {
 		t.Error("missing panic")
 	}
 }
+
+func sleep(ms int) int {
+	time.Sleep(time.Duration(ms) * time.Millisecond)
+	return ms
+}
+
+type testRemoteObject4 struct {
+	Sleep func(context.Context, int) (int, error)
+}
+
+func testInvokeContext(t *testing.T, client hprose.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var result int
+	if err := <-client.InvokeContext(ctx, "sleep", []interface{}{500}, nil, &result); err != context.DeadlineExceeded {
+		t.Error("expected context.DeadlineExceeded, got", err)
+	}
+	var ro *testRemoteObject4
+	client.UseService(&ro)
+	if _, err := ro.Sleep(ctx, 10); err != context.DeadlineExceeded {
+		t.Error("expected context.DeadlineExceeded, got", err)
+	}
+	if ms, err := ro.Sleep(context.Background(), 10); err != nil {
+		t.Error(err.Error())
+	} else if ms != 10 {
+		t.Error(ms)
+	}
+}
+
+func TestHttpServiceInvokeContext(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("sleep", sleep)
+	server := httptest.NewServer(service)
+	defer server.Close()
+	testInvokeContext(t, hprose.NewClient(server.URL))
+}
+
+func TestTcpServiceInvokeContext(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("sleep", sleep)
+	server.Handle()
+	defer server.Stop()
+	testInvokeContext(t, hprose.NewClient(server.URL))
+}
+
+func deadline(ctx context.Context) (time.Duration, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return time.Until(deadline), nil
+	}
+	return 0, errors.New("no deadline")
+}
+
+func TestTcpServiceDeadline(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("deadline", deadline)
+	server.AddFunction("sleep", sleep)
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewTcpClient(server.URL)
+	client.DeadlineEnabled = true
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var d time.Duration
+	if err := <-client.InvokeContext(ctx, "deadline", nil, nil, &d); err != nil {
+		t.Error(err.Error())
+	} else if d <= 0 || d > time.Second {
+		t.Error(d)
+	}
+	if err := <-client.Invoke("deadline", nil, nil, &d); err == nil {
+		t.Error("missing error")
+	}
+	var ro *testRemoteObject4
+	client.UseService(&ro)
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := ro.Sleep(ctx, 100); err != context.DeadlineExceeded {
+		t.Error("expected context.DeadlineExceeded, got", err)
+	}
+}
+
+func TestTcpServiceSkipsExpiredCall(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	var called int32
+	server.AddFunction("sleep", func(ms int) int {
+		atomic.AddInt32(&called, 1)
+		return sleep(ms)
+	})
+	skipped := make(chan error, 1)
+	server.Use(func(name string, args []reflect.Value, context hprose.Context, next hprose.NextInvokeHandler) ([]reflect.Value, error) {
+		time.Sleep(50 * time.Millisecond)
+		results, err := next(name, args, context)
+		skipped <- err
+		return results, err
+	})
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewTcpClient(server.URL)
+	defer client.Close()
+	client.DeadlineEnabled = true
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var ms int
+	if err := <-client.InvokeContext(ctx, "sleep", []interface{}{1}, nil, &ms); err != context.DeadlineExceeded {
+		t.Error("expected context.DeadlineExceeded, got", err)
+	}
+	if err := <-skipped; err != context.DeadlineExceeded {
+		t.Error("expected the service to skip the call, got", err)
+	}
+	if atomic.LoadInt32(&called) != 0 {
+		t.Error("the expired call was executed")
+	}
+}
+
+type testRemoteObject5 struct {
+	Sleep func(int) (<-chan int, <-chan error)
+}
+
+func testFullDuplex(t *testing.T, client hprose.Client) {
+	var ro *testRemoteObject5
+	client.UseService(&ro)
+	start := time.Now()
+	result1, err1 := ro.Sleep(200)
+	result2, err2 := ro.Sleep(10)
+	if err := <-err2; err != nil {
+		t.Error(err.Error())
+	} else if ms := <-result2; ms != 10 {
+		t.Error(ms)
+	}
+	if d := time.Since(start); d >= 200*time.Millisecond {
+		t.Error("the responses are not returned out of order", d)
+	}
+	if err := <-err1; err != nil {
+		t.Error(err.Error())
+	} else if ms := <-result1; ms != 200 {
+		t.Error(ms)
+	}
+	testInvokeContext(t, client)
+}
+
+func TestTcpServiceFullDuplex(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("sleep", sleep)
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewTcpClient(server.URL)
+	client.SetFullDuplex(true)
+	defer client.Close()
+	testFullDuplex(t, client)
+}
+
+func TestTcpServiceMaxConcurrentRequests(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("sleep", sleep)
+	server.SetMaxConcurrentRequests(1)
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewTcpClient(server.URL)
+	client.SetFullDuplex(true)
+	defer client.Close()
+	var ro *testRemoteObject5
+	client.UseService(&ro)
+	start := time.Now()
+	_, err1 := ro.Sleep(50)
+	_, err2 := ro.Sleep(50)
+	if err := <-err1; err != nil {
+		t.Error(err.Error())
+	}
+	if err := <-err2; err != nil {
+		t.Error(err.Error())
+	}
+	if d := time.Since(start); d < 100*time.Millisecond {
+		t.Error("the requests of the connection are not limited", d)
+	}
+}
+
+func TestUnixServiceFullDuplex(t *testing.T) {
+	server := hprose.NewUnixServer("")
+	server.AddFunction("sleep", sleep)
+	if err := server.Handle(); err != nil {
+		t.Error(err)
+	}
+	defer server.Stop()
+	client := hprose.NewUnixClient(server.URL)
+	client.SetFullDuplex(true)
+	defer client.Close()
+	testFullDuplex(t, client)
+}
+
+func TestHttpServiceBatch(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("hello", hello)
+	service.AddMethods(new(testServe))
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	batch := client.Batch()
+	var s string
+	var sum int
+	var swap []int
+	err1 := batch.Invoke("hello", []interface{}{"World"}, nil, &s)
+	err2 := batch.Invoke("sum", []interface{}{1, 2, 3}, nil, &sum)
+	err3 := batch.Invoke("swap", []interface{}{1, 2}, nil, &swap)
+	if err := batch.End(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := <-err1; err != nil {
+		t.Error(err.Error())
+	} else if s != "Hello World!" {
+		t.Error(s)
+	}
+	if err := <-err2; err != nil {
+		t.Error(err.Error())
+	} else if sum != 6 {
+		t.Error(sum)
+	}
+	if err := <-err3; err != nil {
+		t.Error(err.Error())
+	} else if len(swap) != 2 || swap[0] != 2 || swap[1] != 1 {
+		t.Error(swap)
+	}
+	if err := batch.End(); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestTcpServiceBatchError(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.AddFunction("hello", hello)
+	server.AddMethods(new(testServe))
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewClient(server.URL)
+	batch := client.Batch()
+	var s1, s2 string
+	var sum int
+	var e interface{}
+	err1 := batch.Invoke("hello", []interface{}{"World"}, nil, &s1)
+	err2 := batch.Invoke("sum", []interface{}{1}, nil, &sum)
+	err3 := batch.Invoke("panicTest", nil, nil, &e)
+	err4 := batch.Invoke("notExist", nil, nil, &e)
+	err5 := batch.Invoke("hello", []interface{}{"hprose"}, nil, &s2)
+	if err := batch.End(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := <-err1; err != nil {
+		t.Error(err.Error())
+	} else if s1 != "Hello World!" {
+		t.Error(s1)
+	}
+	if err := <-err2; err == nil || err.Error() != "Requires at least two parameters" {
+		t.Error("unexpected error", err)
+	}
+	if err := <-err3; err == nil || err.Error() != "I'm crazy" {
+		t.Error("unexpected error", err)
+	}
+	if err := <-err4; err == nil || err.Error() != "Can't find this method notExist" {
+		t.Error("unexpected error", err)
+	}
+	if err := <-err5; err != nil {
+		t.Error(err.Error())
+	} else if s2 != "Hello hprose!" {
+		t.Error(s2)
+	}
+}
+
+type testCodeError struct {
+	code int
+}
+
+func (e testCodeError) Error() string {
+	return "error with code"
+}
+
+func (e testCodeError) Code() int {
+	return e.code
+}
+
+func (e testCodeError) Data() interface{} {
+	return map[string]interface{}{"field": "name"}
+}
+
+func codeError(code int) error {
+	return testCodeError{code}
+}
+
+func TestHttpServiceRemoteError(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("codeError", codeError)
+	service.AddMethods(new(testServe))
+	service.DebugEnabled = true
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	var result interface{}
+	err := <-client.Invoke("codeError", []interface{}{404}, nil, &result)
+	var e *hprose.RemoteError
+	if !errors.As(err, &e) {
+		t.Fatal("expected *hprose.RemoteError, got", err)
+	}
+	if e.Code != 404 || e.Message != "error with code" {
+		t.Error(e.Code, e.Message)
+	}
+	if data, ok := e.Data.(map[interface{}]interface{}); !ok || len(data) != 1 || data["field"] != "name" {
+		t.Error(e.Data)
+	}
+	err = <-client.Invoke("panicTest", nil, nil, &result)
+	if !errors.As(err, &e) {
+		t.Fatal("expected *hprose.RemoteError, got", err)
+	}
+	if e.Message != "I'm crazy" || !strings.Contains(e.Stack, "goroutine") || e.Code != 0 || e.Data != nil {
+		t.Error(e.Message, e.Stack, e.Code, e.Data)
+	}
+	var raw []byte
+	err = <-client.Invoke("panicTest", nil, &hprose.InvokeOptions{ResultMode: hprose.Raw}, &raw)
+	if err != nil || !strings.Contains(string(raw), "I'm crazy\r\n") || !strings.Contains(string(raw), `s5"stack"`) {
+		t.Error(string(raw), err)
+	}
+}
+
+func addMoney(a, b testMoney) testOrder {
+	sum := testMoney{a.cents + b.cents}
+	return testOrder{Price: sum, Tip: &b}
+}
+
+func TestHttpServiceMarshaler(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("addMoney", addMoney)
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	var result testOrder
+	args := []interface{}{testMoney{1234}, testMoney{66}}
+	if err := <-client.Invoke("addMoney", args, nil, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Price != (testMoney{1300}) || result.Tip == nil || *result.Tip != (testMoney{66}) {
+		t.Error(result)
+	}
+}
+
+type testArith interface {
+	Swap(a int, b int) (int, int)
+	Sum(args ...int) (int, error)
+	Greet(ctx context.Context, name string) (string, error)
+}
+
+type testArithService struct{}
+
+func (testArithService) Swap(a int, b int) (int, int) {
+	return b, a
+}
+
+func (testArithService) Sum(args ...int) (int, error) {
+	return (*testServe)(nil).Sum(args...)
+}
+
+func (testArithService) Greet(ctx context.Context, name string) (string, error) {
+	return hello(name), nil
+}
+
+func (testArithService) NotPublished() {}
+
+type testArithProxy struct {
+	swap  func(int, int) (int, int)
+	sum   func(...int) (int, error)
+	greet func(context.Context, string) (string, error)
+}
+
+func (p *testArithProxy) Swap(a int, b int) (int, int) {
+	return p.swap(a, b)
+}
+
+func (p *testArithProxy) Sum(args ...int) (int, error) {
+	return p.sum(args...)
+}
+
+func (p *testArithProxy) Greet(ctx context.Context, name string) (string, error) {
+	return p.greet(ctx, name)
+}
+
+func init() {
+	hprose.RegisterProxy((*testArith)(nil), func(stub *hprose.Stub) interface{} {
+		return &testArithProxy{
+			swap:  stub.Func("Swap").(func(int, int) (int, int)),
+			sum:   stub.Func("Sum").(func(...int) (int, error)),
+			greet: stub.Func("Greet").(func(context.Context, string) (string, error)),
+		}
+	})
+}
+
+func TestHttpServiceInterface(t *testing.T) {
+	options := hprose.InterfaceOptions{
+		"Greet": {Name: "hello", SimpleMode: true},
+	}
+	service := hprose.NewHttpService()
+	service.AddInterfaceMethods((*testArith)(nil), testArithService{}, options)
+	if len(service.MethodNames) != 3 {
+		t.Error(service.MethodNames)
+	}
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	var arith testArith
+	client.UseService(&arith, options)
+	if a, b := arith.Swap(1, 2); a != 2 || b != 1 {
+		t.Error(a, b)
+	}
+	if sum, err := arith.Sum(1, 2, 3); err != nil || sum != 6 {
+		t.Error(sum, err)
+	}
+	if _, err := arith.Sum(1); err == nil {
+		t.Error("expected error")
+	}
+	if s, err := arith.Greet(context.Background(), "world"); err != nil || s != "Hello world!" {
+		t.Error(s, err)
+	}
+}
+
+type testMember struct {
+	Name    string
+	Friends []*testMember
+	Groups  map[string]int
+}
+
+func addUsers(ctx context.Context, group string, users ...testMember) ([]testMember, error) {
+	return users, nil
+}
+
+func TestHttpServiceDescribe(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFunction("hello", hello, true)
+	service.AddFunction("addUsers", addUsers)
+	service.AddFunction("raw", func(name string, context hprose.Context) []byte {
+		return []byte(name)
+	}, hprose.Raw)
+	service.AddMissingMethod(func(name string, args []reflect.Value) []reflect.Value {
+		return nil
+	})
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	var desc *hprose.ServiceDescription
+	if err := <-client.Invoke(hprose.DescribeMethodName, nil, nil, &desc); err != nil {
+		t.Error(err)
+	}
+	if desc != nil {
+		t.Error("describe should be disabled by default")
+	}
+	service.DescribeEnabled = true
+	if err := <-client.Invoke(hprose.DescribeMethodName, nil, nil, &desc); err != nil {
+		t.Fatal(err)
+	}
+	expected := &hprose.ServiceDescription{
+		Methods: []hprose.MethodDescription{
+			{Name: "hello", Params: []string{"string"}, Results: []string{"string"}, ResultMode: "Normal", SimpleMode: true},
+			{Name: "addUsers", Params: []string{"string", "[]testMember"}, Results: []string{"[]testMember"}, Variadic: true, ResultMode: "Normal"},
+			{Name: "raw", Params: []string{"string"}, Results: []string{"[]uint8"}, ResultMode: "Raw"},
+		},
+		Classes: []hprose.ClassDescription{
+			{Name: "testMember", Fields: []hprose.FieldDescription{
+				{Name: "name", Type: "string"},
+				{Name: "friends", Type: "[]*testMember"},
+				{Name: "groups", Type: "map[string]int"},
+			}},
+		},
+	}
+	if !reflect.DeepEqual(desc, expected) {
+		t.Errorf("%+v", desc)
+	}
+	resp, err := http.Get(server.URL + "?describe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Error(resp.Header.Get("Content-Type"))
+	}
+	desc = nil
+	if err = json.NewDecoder(resp.Body).Decode(&desc); err != nil || !reflect.DeepEqual(desc, expected) {
+		t.Errorf("%+v %v", desc, err)
+	}
+}
+
+func TestTcpServiceDescribeContextParam(t *testing.T) {
+	server := hprose.NewTcpServer("")
+	server.DescribeEnabled = true
+	server.AddFunction("remoteAddr", func(name string, context *hprose.TcpContext) string {
+		return name + context.Conn.RemoteAddr().Network()
+	})
+	server.Handle()
+	defer server.Stop()
+	client := hprose.NewClient(server.URL)
+	defer client.Close()
+	var s string
+	if err := <-client.Invoke("remoteAddr", []interface{}{"hprose:"}, nil, &s); err != nil || s != "hprose:tcp" {
+		t.Error(s, err)
+	}
+	var desc *hprose.ServiceDescription
+	if err := <-client.Invoke(hprose.DescribeMethodName, nil, nil, &desc); err != nil {
+		t.Fatal(err)
+	}
+	expected := []hprose.MethodDescription{
+		{Name: "remoteAddr", Params: []string{"string"}, Results: []string{"string"}, ResultMode: "Normal"},
+	}
+	if !reflect.DeepEqual(desc.Methods, expected) {
+		t.Errorf("%+v", desc.Methods)
+	}
+}
+
+type testProfile struct {
+	Nick   string    `json:"nick"`
+	Born   time.Time `json:"born"`
+	Secret string    `json:"-"`
+}
+
+func init() {
+	hprose.ClassManager.Register(reflect.TypeOf(testProfile{}), "Profile", "json")
+}
+
+func TestHttpServiceOpenRPC(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFilter(hprose.JSONRPCServiceFilter{})
+	service.AddFunction("hello", hello)
+	service.AddFunction("addUsers", addUsers)
+	service.AddFunction("swap", func(a, b int) (int, int) { return b, a }, hprose.ParamNames{"a", "b"})
+	service.AddFunction("profile", func(p *testProfile, context hprose.Context) error { return nil })
+	service.OpenRPCPath = "/openrpc.json"
+	service.OpenRPCInfo.Title = "test"
+	server := httptest.NewServer(service)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/openrpc.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var doc hprose.OpenRPCDocument
+	if err = json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenRPC != hprose.OpenRPCVersion || doc.Info.Title != "test" || len(doc.Methods) != 4 {
+		t.Fatalf("%+v", doc)
+	}
+	data, _ := json.Marshal(doc.Methods)
+	expected := `[{"name":"hello","paramStructure":"by-position","params":[{"name":"arg0","required":true,"schema":{"type":"string"}}],"result":{"name":"result","schema":{"type":"string"}}},` +
+		`{"name":"addUsers","paramStructure":"by-position","params":[{"name":"arg0","required":true,"schema":{"type":"string"}},{"name":"arg1","description":"variadic","schema":{"$ref":"#/components/schemas/testMember"}}],"result":{"name":"result","schema":{"type":"array","items":{"$ref":"#/components/schemas/testMember"}}}},` +
+		`{"name":"swap","paramStructure":"either","params":[{"name":"a","required":true,"schema":{"type":"integer"}},{"name":"b","required":true,"schema":{"type":"integer"}}],"result":{"name":"result","schema":{"type":"array","items":[{"type":"integer"},{"type":"integer"}]}}},` +
+		`{"name":"profile","paramStructure":"by-position","params":[{"name":"arg0","required":true,"schema":{"$ref":"#/components/schemas/Profile"}}],"result":{"name":"result","schema":{"type":"null"}}}]`
+	if string(data) != expected {
+		t.Error(string(data))
+	}
+	data, _ = json.Marshal(doc.Components)
+	expected = `{"schemas":{"Profile":{"type":"object","properties":{"born":{"type":"string","format":"date-time"},"nick":{"type":"string"}}},` +
+		`"testMember":{"type":"object","properties":{"friends":{"type":"array","items":{"$ref":"#/components/schemas/testMember"}},"groups":{"type":"object","additionalProperties":{"type":"integer"}},"name":{"type":"string"}}}}}`
+	if string(data) != expected {
+		t.Error(string(data))
+	}
+}
+
+func TestHttpServiceJSONRPC(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFilter(hprose.JSONRPCServiceFilter{})
+	service.AddFunction("hello", hello)
+	service.AddFunction("sub", func(a, b int) int { return a - b }, hprose.ParamNames{"a", "b"})
+	service.AddFunction("profile", func(p *testProfile) string { return p.Nick })
+	service.AddFunction("fail", func() error { return errors.New("failed") })
+	service.AddFunction("codeError", func() error { return testCodeError{1001} })
+	server := httptest.NewServer(service)
+	defer server.Close()
+	cases := []struct {
+		request  string
+		response string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"hello","params":["world"]}`,
+			`{"jsonrpc":"2.0","id":1,"result":"Hello world!"}`},
+		{`{"jsonrpc":"2.0","method":"hello","params":["world"]}`, ``},
+		{`{"jsonrpc":"2.0","id":"a","method":"sub","params":{"b":1,"a":3}}`,
+			`{"jsonrpc":"2.0","id":"a","result":2}`},
+		{`{"jsonrpc":"2.0","id":2,"method":"profile","params":{"nick":"tom"}}`,
+			`{"jsonrpc":"2.0","id":2,"result":"tom"}`},
+		{`{"jsonrpc":"2.0","id":3,"method":"hello","params":{"name":"world"}}`,
+			`{"jsonrpc":"2.0","id":3,"error":{"code":-32602,"message":"Invalid params","data":"named params are not supported by func(string) string"}}`},
+		{`{"jsonrpc":"2.0","id":4,"method":"sub","params":{"c":1}}`,
+			`{"jsonrpc":"2.0","id":4,"error":{"code":-32602,"message":"Invalid params","data":"unknown param c"}}`},
+		{`{"jsonrpc":"2.0","id":5,"method":"hello","params":[]}`,
+			`{"jsonrpc":"2.0","id":5,"error":{"code":-32602,"message":"Invalid params","data":"wrong number of params"}}`},
+		{`{"jsonrpc":"2.0","id":6,"method":"nothing"}`,
+			`{"jsonrpc":"2.0","id":6,"error":{"code":-32601,"message":"Method not found","data":"nothing"}}`},
+		{`{"jsonrpc":"2.0","id":7,"method":"fail"}`,
+			`{"jsonrpc":"2.0","id":7,"error":{"code":-32603,"message":"failed"}}`},
+		{`{"jsonrpc":"2.0","id":8,"method":"codeError"}`,
+			`{"jsonrpc":"2.0","id":8,"error":{"code":1001,"message":"error with code","data":{"field":"name"}}}`},
+		{`{"jsonrpc":"2.0","id":9,"method":1}`,
+			`{"jsonrpc":"2.0","id":9,"error":{"code":-32600,"message":"Invalid Request","data":"method must be a string"}}`},
+		{`{"jsonrpc":"2.0","id":10,"method":"hello","params":"world"}`,
+			`{"jsonrpc":"2.0","id":10,"error":{"code":-32600,"message":"Invalid Request","data":"params must be an array or object"}}`},
+		{`{"jsonrpc":"2.0","method":"hello"`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"Parse error","data":"unexpected end of JSON input"}}`},
+		{`[]`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}`},
+		{`[1]`,
+			`[{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request"}}]`},
+		{`[{"jsonrpc":"2.0","id":1,"method":"sub","params":[5,3]},{"jsonrpc":"2.0","method":"hello","params":["x"]},` +
+			`{"jsonrpc":"2.0","id":2,"method":"fail"},{"foo":"bar"},{"jsonrpc":"2.0","id":3,"method":"hello","params":["y"]}]`,
+			`[{"jsonrpc":"2.0","id":1,"result":2},{"jsonrpc":"2.0","id":2,"error":{"code":-32603,"message":"failed"}},` +
+				`{"jsonrpc":"2.0","id":null,"error":{"code":-32600,"message":"Invalid Request","data":"method must be a string"}},` +
+				`{"jsonrpc":"2.0","id":3,"result":"Hello y!"}]`},
+		{`[{"jsonrpc":"2.0","method":"hello","params":["x"]}]`, ``},
+		{`{"id":1,"method":"hello","params":["world"]}`,
+			`{"id":1,"result":"Hello world!","error":null}`},
+		{`{"id":null,"method":"hello","params":["world"]}`, ``},
+		{`{"version":"1.1","method":"hello","params":["world"]}`, ``},
+		{`{"jsonrpc":"2.0","id":null,"method":"hello","params":["world"]}`,
+			`{"jsonrpc":"2.0","id":null,"result":"Hello world!"}`},
+	}
+	for _, c := range cases {
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader(c.request))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.response == "" {
+			if len(data) != 0 {
+				t.Error(c.request, string(data))
+			}
+			continue
+		}
+		var expected, actual interface{}
+		json.Unmarshal([]byte(c.response), &expected)
+		if err = json.Unmarshal(data, &actual); err != nil || !reflect.DeepEqual(expected, actual) {
+			t.Error(c.request, string(data), err)
+		}
+	}
+}
+
+func TestHttpServiceJSONRPCClient(t *testing.T) {
+	service := hprose.NewHttpService()
+	service.AddFilter(hprose.JSONRPCServiceFilter{})
+	service.AddFunction("hello", hello)
+	service.AddFunction("sub", func(a, b int) int { return a - b })
+	service.AddFunction("codeError", func() error { return testCodeError{1001} })
+	notified := make(chan string, 2)
+	service.AddFunction("notify", func(s string) { notified <- s })
+	server := httptest.NewServer(service)
+	defer server.Close()
+	client := hprose.NewClient(server.URL)
+	client.AddFilter(hprose.NewJSONRPCClientFilter("2.0"))
+	var s string
+	if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err != nil || s != "Hello world!" {
+		t.Error(s, err)
+	}
+	var r interface{}
+	err := <-client.Invoke("codeError", nil, nil, &r)
+	if e, ok := err.(*hprose.RemoteError); !ok || e.Code != 1001 || e.Message != "error with code" ||
+		e.Data == nil {
+		t.Error(err)
+	}
+	if err = <-client.Invoke("notify", []interface{}{"one"}, &hprose.InvokeOptions{Oneway: true}, &r); err != nil || r != nil {
+		t.Error(r, err)
+	}
+	if n := <-notified; n != "one" {
+		t.Error(n)
+	}
+	batch := client.Batch()
+	var diff int
+	var hi string
+	err1 := batch.Invoke("sub", []interface{}{5, 3}, nil, &diff)
+	err2 := batch.Invoke("notify", []interface{}{"two"}, &hprose.InvokeOptions{Oneway: true}, &r)
+	err3 := batch.Invoke("nothing", nil, nil, &r)
+	err4 := batch.Invoke("hello", []interface{}{"batch"}, nil, &hi)
+	if err = batch.End(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-err1; err != nil || diff != 2 {
+		t.Error(diff, err)
+	}
+	if err = <-err2; err != nil {
+		t.Error(err)
+	}
+	if err = <-err3; err == nil || err.(*hprose.RemoteError).Code != hprose.JSONRPCMethodNotFound {
+		t.Error(err)
+	}
+	if err = <-err4; err != nil || hi != "Hello batch!" {
+		t.Error(hi, err)
+	}
+	if n := <-notified; n != "two" {
+		t.Error(n)
+	}
+}
+
+func TestJSONRPCClientFilterID(t *testing.T) {
+	var ids []float64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		ids = append(ids, request["id"].(float64))
+		w.Write([]byte(`{"jsonrpc":"2.0","id":100,"result":"wrong"}`))
+	}))
+	defer server.Close()
+	for i := 0; i < 2; i++ {
+		client := hprose.NewClient(server.URL)
+		client.AddFilter(hprose.NewJSONRPCClientFilter("2.0"))
+		var s string
+		if err := <-client.Invoke("hello", []interface{}{"world"}, nil, &s); err == nil || s != "" {
+			t.Error(s, err)
+		}
+	}
+	if !reflect.DeepEqual(ids, []float64{1, 1}) {
+		t.Error(ids)
+	}
+}
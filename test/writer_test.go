@@ -12,7 +12,7 @@
  *                                                        *
  * hprose Writer Test for Go.                             *
  *                                                        *
- * LastModified: May 20, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -22,8 +22,10 @@ package hprose_test
 import (
 	"bytes"
 	"container/list"
+	"fmt"
 	"math"
 	"math/big"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -430,3 +432,79 @@ func TestWriterReset(t *testing.T) {
 		t.Error(b.String())
 	}
 }
+
+type testMoney struct {
+	cents int64
+}
+
+func (m testMoney) MarshalHprose(writer *Writer) error {
+	return writer.WriteString(fmt.Sprintf("%d.%02d", m.cents/100, m.cents%100))
+}
+
+func (m *testMoney) UnmarshalHprose(reader *Reader) error {
+	str, err := reader.ReadString()
+	if err != nil {
+		return err
+	}
+	i := strings.IndexByte(str, '.')
+	units, err := strconv.ParseInt(str[:i], 10, 64)
+	if err != nil {
+		return err
+	}
+	cents, err := strconv.ParseInt(str[i+1:], 10, 64)
+	if err != nil {
+		return err
+	}
+	m.cents = units*100 + cents
+	return nil
+}
+
+type testOrder struct {
+	Price  testMoney
+	Prices []testMoney
+	Totals map[string]testMoney
+	Tip    *testMoney
+}
+
+func TestWriterMarshaler(t *testing.T) {
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, true)
+	order := testOrder{
+		Price:  testMoney{1234},
+		Prices: []testMoney{{5}, {9900}},
+		Totals: map[string]testMoney{"total": {9905}},
+	}
+	if err := writer.Serialize(&order); err != nil {
+		t.Error(err.Error())
+	}
+	if err := writer.Serialize(testMoney{100}); err != nil {
+		t.Error(err.Error())
+	}
+	s := `c9"testOrder"4{s5"price"s6"prices"s6"totals"s3"tip"}o0{s5"12.34"a2{s4"0.05"s5"99.00"}m1{s5"total"s5"99.05"}n}s4"1.00"`
+	if b.String() != s {
+		t.Error(b.String())
+	}
+}
+
+func TestWriterNumbers(t *testing.T) {
+	b := new(bytes.Buffer)
+	writer := NewWriter(b, true)
+	f, _, _ := big.ParseFloat("3.14159265358979323846264338327950288", 10, 128, big.ToNearestEven)
+	values := []interface{}{
+		big.NewRat(3, 4),
+		big.NewRat(8, 2),
+		f,
+		complex(1.5, -2),
+		complex64(3),
+		1500 * time.Millisecond,
+	}
+	for _, v := range values {
+		if err := writer.Serialize(v); err != nil {
+			t.Error(err.Error())
+		}
+	}
+	s := `s3"3/4"l4;d3.14159265358979323846264338327950288;a2{d1.5;d-2;}a2{d3;d0;}i1500000000;`
+	if b.String() != s {
+		t.Error(b.String())
+	}
+}
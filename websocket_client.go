@@ -12,7 +12,7 @@
  *                                                        *
  * hprose websocket client for Go.                        *
  *                                                        *
- * LastModified: Jun 24, 2016                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -20,7 +20,9 @@
 package hprose
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
 	"net/http"
 	"net/url"
 	"sync"
@@ -59,6 +61,7 @@ type webSocketTransporter struct {
 	id                    chan uint32
 	sendChan              chan sendMessage
 	recvChan              chan recvCommand
+	done                  chan struct{} // closed when resultLoop exits
 }
 
 // NewWebSocketClient is the constructor of WebSocketClient
@@ -157,7 +160,7 @@ func (trans *webSocketTransporter) idGen() {
 	}
 }
 
-func (trans *webSocketTransporter) sendLoop() {
+func (trans *webSocketTransporter) sendLoop(recvChan chan recvCommand, done chan struct{}) {
 	defer func() {
 		close(trans.sendChan)
 		trans.sendChan = nil
@@ -176,47 +179,56 @@ func (trans *webSocketTransporter) sendLoop() {
 			trans.conn.Close()
 			trans.conn = nil
 			trans.mutex.Unlock()
-			trans.recvChan <- recvCommand{send.id, nil, nil, err}
+			trans.command(recvChan, done, recvCommand{send.id, nil, nil, err})
 		}
 	}
 }
 
-func (trans *webSocketTransporter) resultLoop() {
+// command sends the command to resultLoop, it is dropped when resultLoop
+// has exited
+func (trans *webSocketTransporter) command(recvChan chan recvCommand, done chan struct{}, command recvCommand) {
+	select {
+	case recvChan <- command:
+	case <-done:
+	}
+}
+
+func (trans *webSocketTransporter) resultLoop(recvChan chan recvCommand, done chan struct{}) {
+	defer close(done)
 	results := make(map[uint32](chan recvMessage))
-	for r := range trans.recvChan {
+	for r := range recvChan {
 		if r.recv != nil {
 			results[r.id] = r.recv
 		} else if r.data != nil {
-			recv := results[r.id]
-			delete(results, r.id)
-			recv <- recvMessage{r.data, nil}
-			close(recv)
-		} else if r.err != nil {
-			if r.id != 0 {
-				recv := results[r.id]
+			if recv, ok := results[r.id]; ok {
 				delete(results, r.id)
-				recv <- recvMessage{nil, r.err}
+				recv <- recvMessage{r.data, nil}
 				close(recv)
+			}
+		} else if r.err != nil {
+			if r.id != 0 {
+				if recv, ok := results[r.id]; ok {
+					delete(results, r.id)
+					recv <- recvMessage{nil, r.err}
+					close(recv)
+				}
 			} else {
 				for _, recv := range results {
 					recv <- recvMessage{nil, r.err}
 					close(recv)
 				}
-				break
+				return
 			}
 		}
 	}
-	results = nil
-	close(trans.recvChan)
-	trans.recvChan = nil
 }
 
-func (trans *webSocketTransporter) recvLoop() {
+func (trans *webSocketTransporter) recvLoop(recvChan chan recvCommand, done chan struct{}) {
 	var msgType int
 	var data []byte
 	var err error
 	defer func() {
-		trans.recvChan <- recvCommand{0, nil, nil, err}
+		trans.command(recvChan, done, recvCommand{0, nil, nil, err})
 	}()
 	for {
 		trans.mutex.RLock()
@@ -239,7 +251,7 @@ func (trans *webSocketTransporter) recvLoop() {
 				uint32(data[1])<<16 |
 				uint32(data[2])<<8 |
 				uint32(data[3]))
-			trans.recvChan <- recvCommand{id, nil, data[4:], nil}
+			trans.command(recvChan, done, recvCommand{id, nil, data[4:], nil})
 		}
 	}
 }
@@ -250,17 +262,20 @@ func (trans *webSocketTransporter) getConn(uri string) (err error) {
 		trans.mutex.RUnlock()
 		trans.mutex.Lock()
 		trans.conn, _, err = trans.dialer.Dial(uri, *trans.header)
-		trans.mutex.Unlock()
 		if err != nil {
+			trans.mutex.Unlock()
 			return err
 		}
 		trans.id = make(chan uint32)
 		trans.sendChan = make(chan sendMessage, trans.maxConcurrentRequests)
-		trans.recvChan = make(chan recvCommand, trans.maxConcurrentRequests)
+		recvChan := make(chan recvCommand, trans.maxConcurrentRequests)
+		done := make(chan struct{})
+		trans.recvChan, trans.done = recvChan, done
+		trans.mutex.Unlock()
 		go trans.idGen()
-		go trans.resultLoop()
-		go trans.sendLoop()
-		go trans.recvLoop()
+		go trans.resultLoop(recvChan, done)
+		go trans.sendLoop(recvChan, done)
+		go trans.recvLoop(recvChan, done)
 	} else {
 		trans.mutex.RUnlock()
 	}
@@ -269,9 +284,18 @@ func (trans *webSocketTransporter) getConn(uri string) (err error) {
 
 // SendAndReceive send and receive the data
 func (trans *webSocketTransporter) SendAndReceive(uri string, data []byte) ([]byte, error) {
+	return trans.SendAndReceiveContext(backgroundContext, uri, data)
+}
+
+// SendAndReceiveContext send and receive the data,
+// the request is dropped from the pending requests when the ctx is done
+func (trans *webSocketTransporter) SendAndReceiveContext(ctx context.Context, uri string, data []byte) ([]byte, error) {
 	if err := trans.getConn(uri); err != nil {
 		return nil, err
 	}
+	trans.mutex.RLock()
+	recvChan, done := trans.recvChan, trans.done
+	trans.mutex.RUnlock()
 	id := <-trans.id
 	buf := make([]byte, len(data)+4)
 	buf[0] = byte((id >> 24) & 0xff)
@@ -279,9 +303,36 @@ func (trans *webSocketTransporter) SendAndReceive(uri string, data []byte) ([]by
 	buf[2] = byte((id >> 8) & 0xff)
 	buf[3] = byte(id & 0xff)
 	copy(buf[4:], data)
-	recv := make(chan recvMessage)
-	trans.recvChan <- recvCommand{id, recv, nil, nil}
-	trans.sendChan <- sendMessage{id, buf}
-	result := <-recv
-	return result.data, result.err
+	recv := make(chan recvMessage, 1)
+	select {
+	case recvChan <- recvCommand{id, recv, nil, nil}:
+	case <-done:
+		return nil, errWebSocketClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case trans.sendChan <- sendMessage{id, buf}:
+	case <-done:
+		return nil, errWebSocketClosed
+	case <-ctx.Done():
+		trans.command(recvChan, done, recvCommand{id, nil, nil, ctx.Err()})
+		return nil, ctx.Err()
+	}
+	select {
+	case result := <-recv:
+		return result.data, result.err
+	case <-done:
+		select {
+		case result := <-recv:
+			return result.data, result.err
+		default:
+			return nil, errWebSocketClosed
+		}
+	case <-ctx.Done():
+		trans.command(recvChan, done, recvCommand{id, nil, nil, ctx.Err()})
+		return nil, ctx.Err()
+	}
 }
+
+var errWebSocketClosed = errors.New("The websocket connection has been closed.")
@@ -12,7 +12,7 @@
  *                                                        *
  * hprose websocket service for Go.                       *
  *                                                        *
- * LastModified: May 22, 2015                             *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -32,6 +32,8 @@ import (
 type WebSocketContext struct {
 	*HttpContext
 	WebSocket *websocket.Conn
+	// ConnData is shared by the requests over the connection
+	ConnData *sync.Map
 }
 
 // WebSocketService is the hprose websocket service
@@ -96,13 +98,16 @@ func (service *WebSocketService) ServeHTTP(response http.ResponseWriter, request
 	}
 	defer conn.Close()
 	mutex := sync.Mutex{}
+	connData := new(sync.Map)
 	for {
 		context := new(WebSocketContext)
 		context.HttpContext = new(HttpContext)
 		context.BaseContext = NewBaseContext()
 		context.Response = response
 		context.Request = request
+		context.SetContext(request.Context())
 		context.WebSocket = conn
+		context.ConnData = connData
 		msgType, data, err := conn.ReadMessage()
 		if err != nil {
 			break
@@ -12,7 +12,7 @@
  *                                                        *
  * hprose Writer for Go.                                  *
  *                                                        *
- * LastModified: Jun 3, 2015                              *
+ * LastModified: Oct 18, 2026                             *
  * Author: Ma Bingyao <andot@hprose.com>                  *
  *                                                        *
 \**********************************************************/
@@ -50,8 +50,8 @@ var serializeType = [...]bool{
 	false, // Uintptr
 	true,  // Float32
 	true,  // Float64
-	false, // Complex64
-	false, // Complex128
+	true,  // Complex64
+	true,  // Complex128
 	true,  // Array
 	false, // Chan
 	false, // Func
@@ -145,6 +145,26 @@ func (r *realWriterRefer) resetRef() {
 	}
 }
 
+// HproseMarshaler is the interface implemented by types that
+// can serialize themselves into hprose.
+//
+// The MarshalHprose method with a pointer receiver is only called
+// when the value is addressable, just like encoding/json.
+type HproseMarshaler interface {
+	MarshalHprose(writer *Writer) error
+}
+
+// ObjectWriter is the interface implemented by the structs which have the
+// encoder generated by hprose-gen -codec.
+//
+// WriteTo only writes the field values in the order of the class fields,
+// the class, the reference and the object tags are written by Writer, so
+// the output is the same as the reflective encoder. It is used for the
+// pointer and the addressable value of the struct.
+type ObjectWriter interface {
+	WriteTo(writer *Writer) error
+}
+
 // Writer is a fine-grained operation struct for Hprose serialization
 type Writer struct {
 	Stream    BufWriter
@@ -176,6 +196,11 @@ func (w *Writer) WriteValue(v reflect.Value) (err error) {
 	if v.Kind() == reflect.Ptr && v.IsNil() {
 		return w.WriteNull()
 	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		if m, ok := v.Addr().Interface().(HproseMarshaler); ok {
+			return m.MarshalHprose(w)
+		}
+	}
 	return w.fastSerialize(v.Interface(), v, 0)
 }
 
@@ -235,6 +260,57 @@ func (w *Writer) WriteBigInt(v *big.Int) (err error) {
 	return err
 }
 
+// WriteBigRat to stream, the integer is written as long,
+// and the others are written as string like "a/b"
+func (w *Writer) WriteBigRat(v *big.Rat) error {
+	if v.IsInt() {
+		return w.WriteBigInt(v.Num())
+	}
+	return w.WriteString(v.String())
+}
+
+// WriteBigFloat to stream as double with all the digits of its precision
+func (w *Writer) WriteBigFloat(v *big.Float) (err error) {
+	s := w.Stream
+	if v.IsInf() {
+		if err = s.WriteByte(TagInfinity); err == nil {
+			if v.Sign() > 0 {
+				err = s.WriteByte(TagPos)
+			} else {
+				err = s.WriteByte(TagNeg)
+			}
+		}
+	} else if err = s.WriteByte(TagDouble); err == nil {
+		if _, err = s.WriteString(v.Text('g', -1)); err == nil {
+			err = s.WriteByte(TagSemicolon)
+		}
+	}
+	return err
+}
+
+// WriteComplex64 to stream
+func (w *Writer) WriteComplex64(v complex64) error {
+	return w.WriteComplex128(complex128(v))
+}
+
+// WriteComplex128 to stream, the complex is written as list [real, imag]
+func (w *Writer) WriteComplex128(v complex128) (err error) {
+	w.setRef(&v)
+	s := w.Stream
+	if err = s.WriteByte(TagList); err == nil {
+		if err = w.writeInt(2); err == nil {
+			if err = s.WriteByte(TagOpenbrace); err == nil {
+				if err = w.WriteFloat64(real(v)); err == nil {
+					if err = w.WriteFloat64(imag(v)); err == nil {
+						err = s.WriteByte(TagClosebrace)
+					}
+				}
+			}
+		}
+	}
+	return err
+}
+
 // WriteFloat64 to stream
 func (w *Writer) WriteFloat64(v float64) (err error) {
 	s := w.Stream
@@ -324,6 +400,25 @@ func (w *Writer) WriteArray(v []reflect.Value) (err error) {
 	return err
 }
 
+// WriteListHeader writes the tag and the count of list, the count elements
+// and WriteListFooter must follow it. It's used by the generated code.
+func (w *Writer) WriteListHeader(count int) (err error) {
+	w.setRef(&count)
+	s := w.Stream
+	if err = s.WriteByte(TagList); err == nil && count > 0 {
+		err = w.writeInt(count)
+	}
+	if err == nil {
+		err = s.WriteByte(TagOpenbrace)
+	}
+	return err
+}
+
+// WriteListFooter writes the end of list
+func (w *Writer) WriteListFooter() error {
+	return w.Stream.WriteByte(TagClosebrace)
+}
+
 // Reset the serialize reference count
 func (w *Writer) Reset() {
 	if w.classref != nil {
@@ -338,9 +433,29 @@ func (w *Writer) Reset() {
 // private methods
 
 func (w *Writer) fastSerialize(v interface{}, rv reflect.Value, n int) error {
+	if v != nil {
+		if _, ok := v.(HproseMarshaler); !ok {
+			if c := ConverterManager.getConverter(rv.Type()); c != nil {
+				return c.encode(w, v)
+			}
+			if rv.Kind() == reflect.Ptr {
+				if c := ConverterManager.getConverter(rv.Type().Elem()); c != nil {
+					if rv.IsNil() {
+						return w.WriteNull()
+					}
+					return c.encode(w, rv.Elem().Interface())
+				}
+			}
+		}
+	}
 	switch v := v.(type) {
 	case nil:
 		return w.WriteNull()
+	case HproseMarshaler:
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return w.WriteNull()
+		}
+		return v.MarshalHprose(w)
 	case int:
 		return w.WriteInt64(int64(v))
 	case *int:
@@ -397,6 +512,22 @@ func (w *Writer) fastSerialize(v interface{}, rv reflect.Value, n int) error {
 		return w.WriteBigInt(&v)
 	case *big.Int:
 		return w.WriteBigInt(v)
+	case big.Rat:
+		return w.WriteBigRat(&v)
+	case *big.Rat:
+		return w.WriteBigRat(v)
+	case big.Float:
+		return w.WriteBigFloat(&v)
+	case *big.Float:
+		return w.WriteBigFloat(v)
+	case complex64:
+		return w.WriteComplex64(v)
+	case *complex64:
+		return w.WriteComplex64(*v)
+	case complex128:
+		return w.WriteComplex128(v)
+	case *complex128:
+		return w.WriteComplex128(*v)
 	case string:
 		return w.WriteStringWithRef(v)
 	case *string:
@@ -490,6 +621,9 @@ func (w *Writer) fastSerialize(v interface{}, rv reflect.Value, n int) error {
 }
 
 func (w *Writer) slowSerialize(v interface{}, rv reflect.Value, n int) error {
+	if c := ConverterManager.getConverter(rv.Type()); c != nil {
+		return c.encode(w, rv.Interface())
+	}
 	kind := rv.Kind()
 	switch kind {
 	case reflect.Ptr:
@@ -506,6 +640,10 @@ func (w *Writer) slowSerialize(v interface{}, rv reflect.Value, n int) error {
 		switch x := rv.Interface().(type) {
 		case big.Int:
 			return w.WriteBigInt(&x)
+		case big.Rat:
+			return w.WriteBigRat(&x)
+		case big.Float:
+			return w.WriteBigFloat(&x)
 		case time.Time:
 			return w.writeTimeWithRef(v, x)
 		case list.List:
@@ -550,6 +688,8 @@ func (w *Writer) slowSerialize(v interface{}, rv reflect.Value, n int) error {
 		return w.WriteBool(rv.Bool())
 	case reflect.Float32, reflect.Float64:
 		return w.WriteFloat64(rv.Float())
+	case reflect.Complex64, reflect.Complex128:
+		return w.WriteComplex128(rv.Complex())
 	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
 		return w.WriteUint64(rv.Uint())
 	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
@@ -1344,40 +1484,8 @@ func (w *Writer) writeObject(v interface{}, rv reflect.Value) (err error) {
 	if found {
 		fields = w.fieldsref[index]
 	} else {
-		fieldCache.RLock()
-		cache, found := fieldCache.cache[t]
-		fieldCache.RUnlock()
-		if !found {
-			fieldCache.Lock()
-			if fieldCache.cache == nil {
-				fieldCache.cache = make(map[reflect.Type]*cacheType)
-			}
-			fields = make([]*field, 0)
-			hasAnonymousField := false
-			getFieldsFunc(t, func(f *reflect.StructField) {
-				if len(f.Index) > 1 {
-					hasAnonymousField = true
-				}
-				tag := ClassManager.GetTag(t)
-				if tag == "" {
-					fields = append(fields, &field{firstLetterToLower(f.Name), f.Index})
-				} else {
-					name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
-					name = strings.TrimSpace(strings.SplitN(name, ">", 2)[0])
-					if name == "" {
-						fields = append(fields, &field{firstLetterToLower(f.Name), f.Index})
-					} else if name != "-" {
-						fields = append(fields, &field{name, f.Index})
-					}
-
-				}
-			})
-			cache = &cacheType{fields, hasAnonymousField}
-			fieldCache.cache[t] = cache
-			fieldCache.Unlock()
-		} else {
-			fields = cache.fields
-		}
+		cache := getFieldCache(t)
+		fields = cache.fields
 		if !cache.hasAnonymousField {
 			if index, err = w.writeClass(classname, fields); err != nil {
 				return err
@@ -1391,10 +1499,16 @@ func (w *Writer) writeObject(v interface{}, rv reflect.Value) (err error) {
 	if err = s.WriteByte(TagObject); err == nil {
 		if err = w.writeInt(index); err == nil {
 			if err = s.WriteByte(TagOpenbrace); err == nil {
-				for i := range fields {
-					if err = w.WriteValue(rv.FieldByIndex(fields[i].Index)); err != nil {
+				if o := getObjectWriter(rv); o != nil {
+					if err = o.WriteTo(w); err != nil {
 						return err
 					}
+				} else {
+					for i := range fields {
+						if err = w.WriteValue(rv.FieldByIndex(fields[i].Index)); err != nil {
+							return err
+						}
+					}
 				}
 				err = w.Stream.WriteByte(TagClosebrace)
 			}
@@ -1411,6 +1525,53 @@ func (w *Writer) writeObjectWithRef(v interface{}, rv reflect.Value) error {
 	return err
 }
 
+func getFieldCache(t reflect.Type) *cacheType {
+	fieldCache.RLock()
+	cache, found := fieldCache.cache[t]
+	fieldCache.RUnlock()
+	if found {
+		return cache
+	}
+	fieldCache.Lock()
+	defer fieldCache.Unlock()
+	if fieldCache.cache == nil {
+		fieldCache.cache = make(map[reflect.Type]*cacheType)
+	}
+	fields := make([]*field, 0)
+	hasAnonymousField := false
+	getFieldsFunc(t, func(f *reflect.StructField) {
+		if len(f.Index) > 1 {
+			hasAnonymousField = true
+		}
+		tag := ClassManager.GetTag(t)
+		if tag == "" {
+			fields = append(fields, &field{firstLetterToLower(f.Name), f.Index})
+		} else {
+			name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+			name = strings.TrimSpace(strings.SplitN(name, ">", 2)[0])
+			if name == "" {
+				fields = append(fields, &field{firstLetterToLower(f.Name), f.Index})
+			} else if name != "-" {
+				fields = append(fields, &field{name, f.Index})
+			}
+		}
+	})
+	cache = &cacheType{fields, hasAnonymousField}
+	fieldCache.cache[t] = cache
+	return cache
+}
+
+// getObjectWriter returns nil for the value which isn't addressable,
+// because its fields can't use the HproseMarshaler of pointer receiver.
+func getObjectWriter(rv reflect.Value) ObjectWriter {
+	if rv.CanAddr() {
+		if o, ok := rv.Addr().Interface().(ObjectWriter); ok {
+			return o
+		}
+	}
+	return nil
+}
+
 func (w *Writer) writeClass(classname string, fields []*field) (index int, err error) {
 	s := w.Stream
 	count := len(fields)